@@ -6,12 +6,21 @@ import (
 
 	"keruta-agent/internal/commands"
 	"keruta-agent/internal/config"
+	"keruta-agent/internal/daemon"
 	"keruta-agent/internal/logger"
 
 	"github.com/sirupsen/logrus"
 )
 
+// ビルド時に -ldflags "-X main.Version=... -X main.BuildTime=..." で設定されます
+var (
+	Version   = "dev"
+	BuildTime = "unknown"
+)
+
 func main() {
+	daemon.SetBuildInfo(Version, BuildTime)
+
 	// 設定の初期化
 	if err := config.Init(); err != nil {
 		fmt.Fprintf(os.Stderr, "設定の初期化に失敗しました: %v\n", err)
@@ -27,6 +36,6 @@ func main() {
 	// ルートコマンドの実行
 	if err := commands.Execute(); err != nil {
 		logrus.WithError(err).Error("コマンドの実行に失敗しました")
-		os.Exit(1)
+		os.Exit(commands.ExitCode(err))
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,51 @@
+package api
+
+import "context"
+
+// KerutaAPI はkeruta APIに対する操作を表すインターフェースです
+// コマンドはこのインターフェースに依存し、テストではモックに差し替えられます
+type KerutaAPI interface {
+	// タスクのステータス
+	UpdateTaskStatus(taskID string, status TaskStatus, message string, progress int, errorCode string) error
+	StartTask(taskID string) error
+	SuccessTask(taskID string, message string) error
+	FailTask(taskID string, message string, errorCode string) error
+	CreateAutoFixTask(taskID string, errorMessage string, errorCode string) error
+
+	// ログと成果物
+	SendLog(taskID string, level string, message string) error
+	SendLogBatch(taskID string, logs []LogRequest) error
+	UploadArtifact(taskID string, filePath string, description string) error
+	ListArtifacts(taskID string) ([]Artifact, error)
+	DeleteArtifact(taskID string, artifactID string) error
+
+	// タスク
+	GetTask(taskID string) (*Task, error)
+	GetScript(taskID string) (*Script, error)
+	GetTaskScript(taskID string) (string, error)
+	WaitForInput(taskID string, prompt string) (string, error)
+	GetPendingTasksForSession(sessionID string) ([]*Task, error)
+	GetPendingTasksForWorkspace(workspaceID string) ([]*Task, error)
+
+	// セッション
+	GetSession(sessionID string) (*Session, error)
+	SearchSessionByPartialID(partialID string) (*Session, error)
+	SearchSessionByName(name string) (*Session, error)
+}
+
+var _ KerutaAPI = (*Client)(nil)
+
+// ContextBinder はAPI呼び出しに使うコンテキストを束縛したクライアントを作成できるKerutaAPIです
+type ContextBinder interface {
+	WithContext(ctx context.Context) KerutaAPI
+}
+
+// WithContext はAPI呼び出しにctxのトレースとキャンセルを引き継ぐクライアントを返します
+// シャットダウン中でも送信する必要がある書き込みには、context.WithoutCancelでキャンセルを外したctxを渡します
+// クライアントがContextBinderでない場合（テストのモックなど）はそのまま返します
+func WithContext(client KerutaAPI, ctx context.Context) KerutaAPI {
+	if binder, ok := client.(ContextBinder); ok {
+		return binder.WithContext(ctx)
+	}
+	return client
+}
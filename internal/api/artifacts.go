@@ -1,27 +1,124 @@
 package api
 
 import (
-	"bytes"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"keruta-agent/internal/logger"
+	"keruta-agent/internal/redact"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// textSniffSize はテキストファイルかどうかを判定するために読み込む先頭のサイズです
+	textSniffSize = 8 * 1024
+	// checksumHeader はアップロードする内容のSHA-256チェックサムを送るヘッダーです
+	checksumHeader = "X-Content-SHA256"
+)
+
+// Artifact はサーバーに保存されているタスクの成果物を表します
+type Artifact struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Size        int64       `json:"size"`
+	Description string      `json:"description,omitempty"`
+	ContentType string      `json:"contentType,omitempty"`
+	CreatedAt   interface{} `json:"createdAt,omitempty"`
+}
+
+// listArtifactsHTTP はHTTP APIを使用してタスクの成果物の一覧を取得します
+func listArtifactsHTTP(ctx context.Context, client *Client, taskID string) ([]Artifact, error) {
+	var artifacts []Artifact
+	err := client.do(ctx, &apiRequest{
+		method: http.MethodGet,
+		path:   fmt.Sprintf("/api/v1/tasks/%s/artifacts", taskID),
+	}, &artifacts)
+	if err != nil {
+		return nil, fmt.Errorf("成果物一覧の取得に失敗: %w", err)
+	}
+	return artifacts, nil
+}
+
+// deleteArtifactHTTP はHTTP APIを使用してタスクの成果物を削除します
+func deleteArtifactHTTP(ctx context.Context, client *Client, taskID string, artifactID string) error {
+	err := client.do(ctx, &apiRequest{
+		method: http.MethodDelete,
+		path:   fmt.Sprintf("/api/v1/tasks/%s/artifacts/%s", taskID, url.PathEscape(artifactID)),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("成果物の削除に失敗: %w", err)
+	}
+
+	logger.WithTaskIDAndComponent("api").WithField("artifact_id", artifactID).Info("成果物を削除しました")
+	return nil
+}
+
 // uploadArtifactHTTP はHTTP APIを使用して成果物をアップロードします
-func uploadArtifactHTTP(client *Client, taskID string, filePath string, description string) error {
-	url := fmt.Sprintf("%s/api/v1/tasks/%s/artifacts", client.baseURL, taskID)
+// ファイルはメモリに読み込まずにストリーミングで送信し、SHA-256チェックサムを付与します
+// チャンクサイズより大きいファイルは分割アップロードし、サーバーが対応していない場合は一括でアップロードします
+func uploadArtifactHTTP(ctx context.Context, client *Client, taskID string, filePath string, description string) error {
+	source, err := prepareUploadSource(filePath)
+	if err != nil {
+		return err
+	}
+	defer source.cleanup()
+
+	description = redact.String(description)
+	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
+		"file":        filePath,
+		"description": description,
+		"size":        source.size,
+		"sha256":      source.sha256,
+	}).Debug("成果物をアップロード中")
 
+	if source.size > client.artifactChunkSize() {
+		err = uploadArtifactChunked(ctx, client, taskID, source, description)
+		if errors.Is(err, errChunkedUploadUnsupported) {
+			logger.WithTaskIDAndComponent("api").WithField("file", filePath).Info("サーバーが分割アップロードに対応していないため、一括でアップロードします")
+			err = uploadArtifactMultipart(ctx, client, taskID, source, description)
+		}
+	} else {
+		err = uploadArtifactMultipart(ctx, client, taskID, source, description)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.WithTaskIDAndComponent("api").WithField("file", filePath).Info("成果物をアップロードしました")
+	return nil
+}
+
+// uploadSource はアップロードする内容を表します
+type uploadSource struct {
+	// path は送信する内容のファイルです（テキストファイルの場合はマスク済みの一時ファイル）
+	path string
+	// name はサーバーに保存するファイル名です
+	name   string
+	size   int64
+	sha256 string
+	// temp はpathが一時ファイルかどうかを表します
+	temp bool
+}
+
+// prepareUploadSource はアップロードするファイルのサイズとSHA-256チェックサムを計算します
+// テキストファイルは秘匿情報をマスクした一時ファイルを作成し、その内容を送信します
+func prepareUploadSource(filePath string) (*uploadSource, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		logger.WithTaskIDAndComponent("api").WithError(err).Error("ファイルのオープンに失敗しました")
-		return fmt.Errorf("ファイルのオープンに失敗: %w", err)
+		return nil, fmt.Errorf("ファイルのオープンに失敗: %w", err)
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
@@ -29,79 +126,116 @@ func uploadArtifactHTTP(client *Client, taskID string, filePath string, descript
 		}
 	}()
 
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	// ファイルフィールド
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
-	if err != nil {
-		logger.WithTaskIDAndComponent("api").WithError(err).Error("フォームファイルの作成に失敗しました")
-		return fmt.Errorf("フォームファイルの作成に失敗: %w", err)
-	}
-
-	_, err = io.Copy(part, file)
-	if err != nil {
-		logger.WithTaskIDAndComponent("api").WithError(err).Error("ファイルのコピーに失敗しました")
-		return fmt.Errorf("ファイルのコピーに失敗: %w", err)
+	source := &uploadSource{path: filePath, name: filepath.Base(filePath)}
+	reader := bufio.NewReaderSize(file, textSniffSize)
+	head, err := reader.Peek(textSniffSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("ファイルの読み込みに失敗: %w", err)
 	}
 
-	// 説明フィールド
-	if description != "" {
-		err = writer.WriteField("description", description)
+	hash := sha256.New()
+	if redact.IsText(head, errors.Is(err, io.EOF)) {
+		// テキストファイルに含まれる秘匿情報はマスクしてからアップロードする
+		temp, err := os.CreateTemp("", "keruta-artifact-*")
 		if err != nil {
-			logger.WithTaskIDAndComponent("api").WithError(err).Error("説明フィールドの書き込みに失敗しました")
-			return fmt.Errorf("説明フィールドの書き込みに失敗: %w", err)
+			return nil, fmt.Errorf("一時ファイルの作成に失敗: %w", err)
+		}
+		source.path = temp.Name()
+		source.temp = true
+		source.size, err = redact.Copy(io.MultiWriter(temp, hash), reader)
+		if closeErr := temp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			source.cleanup()
+			logger.WithTaskIDAndComponent("api").WithError(err).Error("ファイルの読み込みに失敗しました")
+			return nil, fmt.Errorf("ファイルの読み込みに失敗: %w", err)
+		}
+	} else {
+		if source.size, err = io.Copy(hash, reader); err != nil {
+			logger.WithTaskIDAndComponent("api").WithError(err).Error("ファイルの読み込みに失敗しました")
+			return nil, fmt.Errorf("ファイルの読み込みに失敗: %w", err)
 		}
 	}
+	source.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return source, nil
+}
 
-	if err := writer.Close(); err != nil {
-		logger.WithTaskIDAndComponent("api").WithError(err).Error("マルチパートライターのクローズに失敗しました")
-		return fmt.Errorf("マルチパートライターのクローズに失敗: %w", err)
+// cleanup は作成した一時ファイルを削除します
+func (s *uploadSource) cleanup() {
+	if !s.temp {
+		return
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		logger.WithTaskIDAndComponent("api").WithError(err).Warning("一時ファイルの削除に失敗しました")
 	}
+}
+
+// uploadArtifactMultipart は成果物をマルチパートで一括してアップロードします
+// ボディはio.Pipeでファイルを読みながら作成するため、ファイル全体をメモリに保持しません
+func uploadArtifactMultipart(ctx context.Context, client *Client, taskID string, source *uploadSource, description string) error {
+	// 再試行時も同じContent-Typeを使えるよう、境界文字列を固定する
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	return client.do(ctx, &apiRequest{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/api/v1/tasks/%s/artifacts", taskID),
+		newBody: func() (io.Reader, error) {
+			return source.multipartBody(boundary, description)
+		},
+		contentType: "multipart/form-data; boundary=" + boundary,
+		headers:     map[string]string{checksumHeader: source.sha256},
+		logBody:     map[string]string{"file": source.name, "description": description, "sha256": source.sha256},
+		warnOnly:    true,
+	}, nil)
+}
 
-	req, err := http.NewRequest("POST", url, &buf)
+// multipartBody はマルチパートのボディをストリーミングで書き込むReaderを返します
+// メタデータ（説明・サイズ・チェックサム）はファイルより前に書き込み、サーバーが受信しながら検証できるようにします
+func (s *uploadSource) multipartBody(boundary, description string) (io.Reader, error) {
+	file, err := os.Open(s.path)
 	if err != nil {
-		logger.WithTaskIDAndComponent("api").WithError(err).Error("リクエストの作成に失敗しました")
-		return fmt.Errorf("リクエストの作成に失敗: %w", err)
+		logger.WithTaskIDAndComponent("api").WithError(err).Error("ファイルのオープンに失敗しました")
+		return nil, fmt.Errorf("ファイルのオープンに失敗: %w", err)
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	if client.token != "" {
-		req.Header.Set("Authorization", "Bearer "+client.token)
-	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer file.Close()
+		pw.CloseWithError(s.writeMultipart(pw, file, boundary, description))
+	}()
+	return pr, nil
+}
 
-	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
-		"file":        filePath,
-		"description": description,
-	}).Debug("成果物をアップロード中")
+func (s *uploadSource) writeMultipart(w io.Writer, file io.Reader, boundary, description string) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return fmt.Errorf("マルチパートの境界文字列の設定に失敗: %w", err)
+	}
 
-	// リクエストヘッダーを収集
-	headers := make(map[string]string)
-	for k, v := range req.Header {
-		if len(v) > 0 {
-			headers[k] = v[0]
+	// 説明フィールド
+	if description != "" {
+		if err := writer.WriteField("description", description); err != nil {
+			return fmt.Errorf("説明フィールドの書き込みに失敗: %w", err)
 		}
 	}
+	if err := writer.WriteField("size", strconv.FormatInt(s.size, 10)); err != nil {
+		return fmt.Errorf("サイズフィールドの書き込みに失敗: %w", err)
+	}
+	if err := writer.WriteField("sha256", s.sha256); err != nil {
+		return fmt.Errorf("チェックサムフィールドの書き込みに失敗: %w", err)
+	}
 
-	resp, err := client.httpClient.Do(req)
-
-	// API呼び出しエラーの詳細をログに記録（警告レベル）
-	logAPIError("POST", url, headers, map[string]string{"file": filePath, "description": description}, resp, err, true)
-
+	// ファイルフィールド
+	part, err := writer.CreateFormFile("file", s.name)
 	if err != nil {
-		return fmt.Errorf("API呼び出しに失敗: %w", err)
+		return fmt.Errorf("フォームファイルの作成に失敗: %w", err)
 	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API呼び出しが失敗しました: %d - %s", resp.StatusCode, string(body))
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("ファイルのコピーに失敗: %w", err)
 	}
 
-	logger.WithTaskIDAndComponent("api").WithField("file", filePath).Info("成果物をアップロードしました")
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("マルチパートライターのクローズに失敗: %w", err)
+	}
 	return nil
 }
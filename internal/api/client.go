@@ -1,14 +1,15 @@
 package api
 
 import (
-	"bufio"
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"keruta-agent/internal/config"
@@ -83,18 +84,38 @@ type Client struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+	// retry はAPI呼び出しの再試行方針です（ゼロ値の場合は再試行しません）
+	retry RetryPolicy
+	// chunkSize は成果物を分割アップロードする際の1チャンクのサイズです（0の場合は設定値を使用します）
+	chunkSize int64
+	// ctx はAPI呼び出しに使うコンテキストです（nilの場合はc.context()）
+	ctx context.Context
+	// batchUnsupported はサーバーがログの一括送信に対応していないかどうかです（WithContextで作成したクライアントと共有します）
+	batchUnsupported *atomic.Bool
 }
 
 // TaskStatus はタスクのステータスを表します
 type TaskStatus string
 
 const (
+	TaskStatusPending         TaskStatus = "PENDING"
 	TaskStatusProcessing      TaskStatus = "IN_PROGRESS"
 	TaskStatusCompleted       TaskStatus = "COMPLETED"
 	TaskStatusFailed          TaskStatus = "FAILED"
 	TaskStatusWaitingForInput TaskStatus = "WAITING_FOR_INPUT"
+	TaskStatusCancelled       TaskStatus = "CANCELLED"
 )
 
+// IsTerminal はステータスが終了状態（完了・失敗・キャンセル）かどうかを返します
+func (s TaskStatus) IsTerminal() bool {
+	switch s {
+	case TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
 // TaskUpdateRequest はタスク更新リクエストを表します
 type TaskUpdateRequest struct {
 	Status    TaskStatus `json:"status"`
@@ -103,10 +124,40 @@ type TaskUpdateRequest struct {
 	ErrorCode string     `json:"errorCode,omitempty"`
 }
 
+// ログの送信元を表します
+const (
+	LogSourceAgent        = "agent"
+	LogSourceClaudeStdout = "claude-stdout"
+	LogSourceClaudeStderr = "claude-stderr"
+	LogSourceGit          = "git"
+	// LogSourceScript はタスクのスクリプトからkeruta logで送信されたログです
+	LogSourceScript = "script"
+)
+
 // LogRequest はログ送信リクエストを表します
 type LogRequest struct {
 	Level   string `json:"level"`
 	Message string `json:"message"`
+	// Timestamp はログが記録された時刻です
+	Timestamp time.Time `json:"timestamp"`
+	// Sequence はログの記録順を表す番号です（送信順ではなく記録順に並べるために使用します）
+	// 番号はタスクごとではなく、すべてのタスクのログで共通の記録時刻順に並びます
+	Sequence uint64 `json:"sequence"`
+	// Source はログの送信元です（agent, claude-stdout, claude-stderr, git など）
+	Source string `json:"source,omitempty"`
+	// Fields はログに付与された構造化フィールドです
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// NewLogRequest は現在時刻と次のシーケンス番号を付与したログ送信リクエストを作成します
+func NewLogRequest(source, level, message string) LogRequest {
+	return LogRequest{
+		Level:     level,
+		Message:   message,
+		Timestamp: time.Now(),
+		Sequence:  logger.NextSequence(),
+		Source:    source,
+	}
 }
 
 // Script はスクリプト情報を表します
@@ -149,7 +200,26 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: config.GetTimeout(),
 		},
+		retry:            DefaultRetryPolicy(),
+		chunkSize:        config.GetArtifactsChunkSize(),
+		batchUnsupported: new(atomic.Bool),
+	}
+}
+
+// WithContext はAPI呼び出しにctxのトレースとキャンセルを引き継ぐクライアントを返します
+// ctxがキャンセルされると、送信中のリクエストと再試行の待機を中断します
+func (c *Client) WithContext(ctx context.Context) KerutaAPI {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
+// context はAPI呼び出しに使うコンテキストを返します
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
 }
 
 // GetWebSocketClient はWebSocketクライアントを取得します
@@ -161,140 +231,49 @@ func (c *Client) GetWebSocketClient(taskID string) (interface{}, error) {
 
 // UpdateTaskStatus はタスクのステータスを更新します
 func (c *Client) UpdateTaskStatus(taskID string, status TaskStatus, message string, progress int, errorCode string) error {
-	// HTTP APIでステータス更新
-	err := updateTaskStatusHTTP(c, taskID, status, message, progress, errorCode)
-	if err != nil {
-		return err
-	}
-	return nil
+	return updateTaskStatusHTTP(c.context(), c, taskID, status, message, progress, errorCode)
 }
 
 // SendLog はログを送信します
 func (c *Client) SendLog(taskID string, level string, message string) error {
-	// HTTP APIでログ送信
-	err := sendLogHTTP(c, taskID, level, message)
-	if err != nil {
-		return err
+	return sendLogHTTP(c.context(), c, taskID, level, message)
+}
+
+// SendLogBatch は複数のログをまとめて送信します
+func (c *Client) SendLogBatch(taskID string, logs []LogRequest) error {
+	if len(logs) == 0 {
+		return nil
 	}
-	return nil
+	return sendLogBatchHTTP(c.context(), c, taskID, logs)
 }
 
 // UploadArtifact は成果物をアップロードします
 func (c *Client) UploadArtifact(taskID string, filePath string, description string) error {
-	return uploadArtifactHTTP(c, taskID, filePath, description)
+	return uploadArtifactHTTP(c.context(), c, taskID, filePath, description)
+}
+
+// ListArtifacts はタスクの成果物の一覧を取得します
+func (c *Client) ListArtifacts(taskID string) ([]Artifact, error) {
+	return listArtifactsHTTP(c.context(), c, taskID)
+}
+
+// DeleteArtifact はタスクの成果物を削除します
+func (c *Client) DeleteArtifact(taskID string, artifactID string) error {
+	return deleteArtifactHTTP(c.context(), c, taskID, artifactID)
 }
 
 // WaitForInput は入力待ち状態を通知し、入力を待機します
 func (c *Client) WaitForInput(taskID string, prompt string) (string, error) {
 	// 環境変数でHTTP入力モードを制御
-	useHTTP := os.Getenv("KERUTA_USE_HTTP_INPUT") == "true"
-
-	if useHTTP {
-		// HTTP APIを使用して入力を待機
-		logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
-			"taskID": taskID,
-			"prompt": prompt,
-		}).Info("HTTP APIを通じて入力を待機中...")
-
-		// 入力待ち状態をAPIに通知
-		url := fmt.Sprintf("%s/api/v1/tasks/%s/input-request", c.baseURL, taskID)
-		reqBody := map[string]string{
-			"prompt": prompt,
-		}
-
-		jsonData, err := json.Marshal(reqBody)
-		if err != nil {
-			return "", fmt.Errorf("リクエストボディのマーシャルに失敗: %w", err)
-		}
-
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return "", fmt.Errorf("リクエストの作成に失敗: %w", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		if c.token != "" {
-			req.Header.Set("Authorization", "Bearer "+c.token)
-		}
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			logger.WithTaskIDAndComponent("api").WithError(err).Warning("入力リクエストの送信に失敗しました")
-		} else {
-			if closeErr := resp.Body.Close(); closeErr != nil {
-				logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-			}
-		}
-
-		// 入力が提供されるまでポーリング
-		pollURL := fmt.Sprintf("%s/api/v1/tasks/%s/input", c.baseURL, taskID)
-		maxRetries := 12 * 60 * 24 * 7
-		for i := 0; i < maxRetries; i++ {
-			pollReq, err := http.NewRequest("GET", pollURL, nil)
-			if err != nil {
-				return "", fmt.Errorf("入力ポーリングリクエストの作成に失敗: %w", err)
-			}
-
-			pollReq.Header.Set("Content-Type", "application/json")
-			if c.token != "" {
-				pollReq.Header.Set("Authorization", "Bearer "+c.token)
-			}
-			pollResp, err := c.httpClient.Do(pollReq)
-			if err != nil {
-				logger.WithTaskIDAndComponent("api").WithError(err).Warning("入力のポーリングに失敗しました、再試行します")
-				time.Sleep(5 * time.Second)
-				continue
-			}
-
-			// 入力が提供された場合
-			if pollResp.StatusCode == http.StatusOK {
-				var inputResp struct {
-					Input string `json:"input"`
-				}
-
-				if err := json.NewDecoder(pollResp.Body).Decode(&inputResp); err != nil {
-					if closeErr := pollResp.Body.Close(); closeErr != nil {
-						logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("ポーリングレスポンスボディのクローズに失敗しました")
-					}
-					return "", fmt.Errorf("入力レスポンスのデコードに失敗: %w", err)
-				}
-
-				if closeErr := pollResp.Body.Close(); closeErr != nil {
-					logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("ポーリングレスポンスボディのクローズに失敗しました")
-				}
-				logger.WithTaskIDAndComponent("api").Info("入力を受け取りました")
-				return inputResp.Input, nil
-			}
-
-			if closeErr := pollResp.Body.Close(); closeErr != nil {
-				logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("ポーリングレスポンスボディのクローズに失敗しました")
-			}
-			// 入力がまだ提供されていない場合は待機
-			time.Sleep(5 * time.Second)
-		}
-
-		return "", fmt.Errorf("入力待ちがタイムアウトしました")
-	} else {
-		// 標準入力から入力を受け付ける
-		logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
-			"taskID": taskID,
-			"prompt": prompt,
-		}).Info("標準入力からの入力を待機中...")
-
-		// プロンプトを表示
-		fmt.Printf("%s ", prompt)
-
-		reader := bufio.NewReader(os.Stdin)
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			return "", fmt.Errorf("標準入力の読み取りに失敗 (taskID: %s, prompt: %s): %w", taskID, prompt, err)
-		}
-		return input, nil
+	if os.Getenv("KERUTA_USE_HTTP_INPUT") == "true" {
+		return waitForInputHTTP(c.context(), c, taskID, prompt)
 	}
+	return waitForInputStdin(taskID, prompt)
 }
 
 // GetScript はタスクのスクリプトを取得します
 func (c *Client) GetScript(taskID string) (*Script, error) {
-	return getScriptHTTP(c, taskID)
+	return getScriptHTTP(c.context(), c, taskID)
 }
 
 // Session はセッション情報を表します
@@ -323,112 +302,43 @@ type SessionTemplateConfig struct {
 
 // GetSession はセッション情報を取得します
 func (c *Client) GetSession(sessionID string) (*Session, error) {
-	url := fmt.Sprintf("%s/api/v1/sessions/%s", c.baseURL, sessionID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API呼び出しが失敗しました: %d - %s (URL: %s)", resp.StatusCode, string(body), url)
-	}
-
 	var session Session
-	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
-		return nil, fmt.Errorf("レスポンスのデコードに失敗: %w", err)
+	err := c.do(c.context(), &apiRequest{
+		method:   http.MethodGet,
+		path:     fmt.Sprintf("/api/v1/sessions/%s", sessionID),
+		warnOnly: true,
+	}, &session)
+	if err != nil {
+		return nil, err
 	}
-
 	return &session, nil
 }
 
 // GetPendingTasksForSession はセッション用の保留中タスクを取得します
 func (c *Client) GetPendingTasksForSession(sessionID string) ([]*Task, error) {
-	url := fmt.Sprintf("%s/api/v1/sessions/%s/tasks?status=PENDING", c.baseURL, sessionID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API呼び出しが失敗しました: %d - %s", resp.StatusCode, string(body))
-	}
-
 	var tasks []*Task
-	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
-		return nil, fmt.Errorf("レスポンスのデコードに失敗: %w", err)
+	err := c.do(c.context(), &apiRequest{
+		method:   http.MethodGet,
+		path:     fmt.Sprintf("/api/v1/sessions/%s/tasks?status=PENDING", sessionID),
+		warnOnly: true,
+	}, &tasks)
+	if err != nil {
+		return nil, err
 	}
-
 	return tasks, nil
 }
 
 // GetPendingTasksForWorkspace はワークスペース用の保留中タスクを取得します
 func (c *Client) GetPendingTasksForWorkspace(workspaceID string) ([]*Task, error) {
-	url := fmt.Sprintf("%s/api/v1/workspaces/%s/tasks/pending", c.baseURL, workspaceID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API呼び出しが失敗しました: %d - %s", resp.StatusCode, string(body))
-	}
-
 	var tasks []*Task
-	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
-		return nil, fmt.Errorf("レスポンスのデコードに失敗: %w", err)
+	err := c.do(c.context(), &apiRequest{
+		method:   http.MethodGet,
+		path:     fmt.Sprintf("/api/v1/workspaces/%s/tasks/pending", workspaceID),
+		warnOnly: true,
+	}, &tasks)
+	if err != nil {
+		return nil, err
 	}
-
 	return tasks, nil
 }
 
@@ -458,67 +368,28 @@ func (c *Client) GetTaskScript(taskID string) (string, error) {
 
 // GetTask はタスクの詳細情報を取得します
 func (c *Client) GetTask(taskID string) (*Task, error) {
-	url := fmt.Sprintf("%s/api/v1/tasks/%s", c.baseURL, taskID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("タスク取得リクエストの作成に失敗: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("タスク取得リクエストに失敗: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("タスク取得に失敗: %s", resp.Status)
-	}
-
 	var task Task
-	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
-		return nil, fmt.Errorf("タスクレスポンスのパースに失敗: %w", err)
+	err := c.do(c.context(), &apiRequest{
+		method:   http.MethodGet,
+		path:     fmt.Sprintf("/api/v1/tasks/%s", taskID),
+		warnOnly: true,
+	}, &task)
+	if err != nil {
+		return nil, fmt.Errorf("タスク取得に失敗: %w", err)
 	}
-
 	return &task, nil
 }
 
 // SearchSessionByPartialID は部分的なセッションIDで検索し、完全なUUIDを取得します
 func (c *Client) SearchSessionByPartialID(partialID string) (*Session, error) {
-	url := fmt.Sprintf("%s/api/v1/sessions/search/partial-id?partialId=%s", c.baseURL, partialID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API呼び出しが失敗しました: %d - %s (URL: %s)", resp.StatusCode, string(body), url)
-	}
-
 	var sessions []Session
-	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
-		return nil, fmt.Errorf("レスポンスのデコードに失敗: %w", err)
+	err := c.do(c.context(), &apiRequest{
+		method:   http.MethodGet,
+		path:     "/api/v1/sessions/search/partial-id?partialId=" + url.QueryEscape(partialID),
+		warnOnly: true,
+	}, &sessions)
+	if err != nil {
+		return nil, err
 	}
 
 	// 結果が空の場合
@@ -539,36 +410,14 @@ func (c *Client) SearchSessionByPartialID(partialID string) (*Session, error) {
 
 // SearchSessionByName は名前による完全一致でセッションを検索します
 func (c *Client) SearchSessionByName(name string) (*Session, error) {
-	url := fmt.Sprintf("%s/api/v1/sessions/search?name=%s", c.baseURL, name)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API呼び出しが失敗しました: %d - %s (URL: %s)", resp.StatusCode, string(body), url)
-	}
-
 	var sessions []Session
-	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
-		return nil, fmt.Errorf("レスポンスのデコードに失敗: %w", err)
+	err := c.do(c.context(), &apiRequest{
+		method:   http.MethodGet,
+		path:     "/api/v1/sessions/search?name=" + url.QueryEscape(name),
+		warnOnly: true,
+	}, &sessions)
+	if err != nil {
+		return nil, err
 	}
 
 	// 完全一致のセッションを探す
@@ -583,60 +432,24 @@ func (c *Client) SearchSessionByName(name string) (*Session, error) {
 
 // CreateAutoFixTask は自動修正タスクを作成します
 func (c *Client) CreateAutoFixTask(taskID string, errorMessage string, errorCode string) error {
-	url := fmt.Sprintf("%s/api/v1/tasks/%s/auto-fix", c.baseURL, taskID)
-
-	reqBody := map[string]string{
-		"errorMessage": errorMessage,
-		"errorCode":    errorCode,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		logger.WithTaskIDAndComponent("api").WithError(err).Error("リクエストボディのマーシャルに失敗しました")
-		return fmt.Errorf("リクエストボディのマーシャルに失敗: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		logger.WithTaskIDAndComponent("api").WithError(err).Error("リクエストの作成に失敗しました")
-		return fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-
 	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
 		"errorMessage": errorMessage,
 		"errorCode":    errorCode,
 	}).Info("自動修正タスクを作成中")
 
-	// リクエストヘッダーを収集
-	headers := make(map[string]string)
-	for k, v := range req.Header {
-		if len(v) > 0 {
-			headers[k] = v[0]
-		}
-	}
-
-	resp, err := c.httpClient.Do(req)
-
-	// API呼び出しエラーの詳細をログに記録（警告レベル）
-	logAPIError("POST", url, headers, reqBody, resp, err, true)
-
+	// 自動修正タスクの作成失敗は警告として記録する
+	// サーバーがタスクを作成した後に応答が失敗した場合に重複して作成しないよう、再試行しない
+	err := c.doOnce(c.context(), &apiRequest{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/api/v1/tasks/%s/auto-fix", taskID),
+		body: map[string]string{
+			"errorMessage": errorMessage,
+			"errorCode":    errorCode,
+		},
+		warnOnly: true,
+	}, nil)
 	if err != nil {
-		return fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API呼び出しが失敗しました: %d - %s", resp.StatusCode, string(body))
+		return err
 	}
 
 	logger.WithTaskIDAndComponent("api").Info("自動修正タスクを作成しました")
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,9 +14,15 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// inputPollInterval は入力をポーリングする間隔です
+const inputPollInterval = 5 * time.Second
+
 // waitForInputStdin は標準入力から入力を待機します
-func waitForInputStdin(prompt string) (string, error) {
-	logger.WithTaskIDAndComponent("api").WithField("prompt", prompt).Info("標準入力からの入力を待機中...")
+func waitForInputStdin(taskID string, prompt string) (string, error) {
+	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
+		"taskID": taskID,
+		"prompt": prompt,
+	}).Info("標準入力からの入力を待機中...")
 
 	// プロンプトを表示
 	fmt.Printf("%s ", prompt)
@@ -23,58 +30,80 @@ func waitForInputStdin(prompt string) (string, error) {
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
 	if err != nil {
-		return "", fmt.Errorf("標準入力の読み取りに失敗: %w", err)
+		return "", fmt.Errorf("標準入力の読み取りに失敗 (taskID: %s, prompt: %s): %w", taskID, prompt, err)
 	}
 	return input, nil
 }
 
 // waitForInputHTTP はHTTP APIを使用して入力を待機します
-func waitForInputHTTP(client *Client, taskID string, prompt string) (string, error) {
-	url := fmt.Sprintf("%s/api/v1/tasks/%s/input", client.baseURL, taskID)
-
+func waitForInputHTTP(ctx context.Context, client *Client, taskID string, prompt string) (string, error) {
 	// 入力待ち状態をログに記録
 	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
 		"taskID": taskID,
 		"prompt": prompt,
 	}).Info("HTTP APIを通じて入力を待機中...")
 
+	// 入力待ち状態をAPIに通知
+	err := client.do(ctx, &apiRequest{
+		method:   http.MethodPost,
+		path:     fmt.Sprintf("/api/v1/tasks/%s/input-request", taskID),
+		body:     map[string]string{"prompt": prompt},
+		warnOnly: true,
+	}, nil)
+	if err != nil {
+		logger.WithTaskIDAndComponent("api").WithError(err).Warning("入力リクエストの送信に失敗しました")
+	}
+
 	// 入力が提供されるまでポーリング
 	maxRetries := 12 * 60 * 24 * 7
 	for i := 0; i < maxRetries; i++ {
-		// 入力をチェック
-		req, err := http.NewRequest("GET", url, nil)
+		input, ok, err := pollInput(ctx, client, taskID)
 		if err != nil {
-			return "", fmt.Errorf("入力リクエストの作成に失敗: %w", err)
+			return "", err
 		}
-
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := client.httpClient.Do(req)
-		if err != nil {
-			logger.WithTaskIDAndComponent("api").WithError(err).Warning("入力のチェックに失敗しました、再試行します")
-			time.Sleep(5 * time.Second)
-			continue
+		if ok {
+			logger.WithTaskIDAndComponent("api").Info("入力を受け取りました")
+			return input, nil
 		}
 
-		defer resp.Body.Close()
-
-		// 入力が提供された場合
-		if resp.StatusCode == http.StatusOK {
-			var inputResp struct {
-				Input string `json:"input"`
-			}
+		// 入力がまだ提供されていない場合は待機（デーモンの停止で中断する）
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(inputPollInterval):
+		}
+	}
 
-			if err := json.NewDecoder(resp.Body).Decode(&inputResp); err != nil {
-				return "", fmt.Errorf("入力レスポンスのデコードに失敗: %w", err)
-			}
+	return "", fmt.Errorf("入力待ちがタイムアウトしました")
+}
 
-			logger.WithTaskIDAndComponent("api").Info("入力を受け取りました")
-			return inputResp.Input, nil
+// pollInput は入力が提供されているかを1回確認します
+// 入力がまだ提供されていない場合やAPIに接続できない場合はokがfalseになります
+// ctxがキャンセルされた場合はctxのエラーを返します
+func pollInput(ctx context.Context, client *Client, taskID string) (string, bool, error) {
+	resp, err := client.send(ctx, &apiRequest{
+		method: http.MethodGet,
+		path:   fmt.Sprintf("/api/v1/tasks/%s/input", taskID),
+		silent: true,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", false, ctx.Err()
 		}
+		logger.WithTaskIDAndComponent("api").WithError(err).Warning("入力のポーリングに失敗しました、再試行します")
+		return "", false, nil
+	}
+	defer closeResponse(resp)
 
-		// 入力がまだ提供されていない場合は待機
-		time.Sleep(5 * time.Second)
+	if resp.StatusCode != http.StatusOK {
+		return "", false, nil
 	}
 
-	return "", fmt.Errorf("入力待ちがタイムアウトしました")
+	var inputResp struct {
+		Input string `json:"input"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&inputResp); err != nil {
+		return "", false, fmt.Errorf("入力レスポンスのデコードに失敗: %w", err)
+	}
+	return inputResp.Input, true, nil
 }
@@ -1,11 +1,11 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"time"
 
 	"keruta-agent/internal/logger"
 
@@ -13,60 +13,100 @@ import (
 )
 
 // sendLogHTTP はHTTP APIを使用してログを送信します
-func sendLogHTTP(client *Client, taskID string, level string, message string) error {
-	url := fmt.Sprintf("%s/api/v1/tasks/%s/logs", client.baseURL, taskID)
+func sendLogHTTP(ctx context.Context, client *Client, taskID string, level string, message string) error {
+	reqBody := NewLogRequest(LogSourceAgent, level, message)
 
-	reqBody := LogRequest{
-		Level:   level,
-		Message: message,
-	}
+	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
+		"level":   level,
+		"message": message,
+	}).Debug("ログを送信中")
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("リクエストボディのマーシャルに失敗: %w", err)
-	}
+	// ログ送信の失敗は警告として記録する
+	return client.do(ctx, &apiRequest{
+		method:   http.MethodPost,
+		path:     fmt.Sprintf("/api/v1/tasks/%s/logs", taskID),
+		body:     reqBody,
+		warnOnly: true,
+	}, nil)
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("リクエストの作成に失敗: %w", err)
-	}
+var _ logger.BatchLogSender = (*Client)(nil)
 
-	req.Header.Set("Content-Type", "application/json")
-	if client.token != "" {
-		req.Header.Set("Authorization", "Bearer "+client.token)
+// SendLogRecords はロガーのバッファに溜まったログをまとめて送信します（logger.BatchLogSenderの実装）
+func (c *Client) SendLogRecords(taskID string, records []logger.Record) error {
+	logs := make([]LogRequest, 0, len(records))
+	for _, record := range records {
+		logs = append(logs, LogRequest{
+			Level:     record.Level,
+			Message:   record.Message,
+			Timestamp: record.Time,
+			Sequence:  record.Sequence,
+			Source:    record.Source,
+			Fields:    record.Fields,
+		})
 	}
+	return c.SendLogBatch(taskID, logs)
+}
 
-	logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
-		"level":   level,
-		"message": message,
-	}).Debug("ログを送信中")
+// LogBatchRequest はログの一括送信リクエストを表します
+type LogBatchRequest struct {
+	Logs []LogRequest `json:"logs"`
+}
 
-	// リクエストヘッダーを収集
-	headers := make(map[string]string)
-	for k, v := range req.Header {
-		if len(v) > 0 {
-			headers[k] = v[0]
+// sendLogBatchHTTP はHTTP APIを使用して複数のログを一括送信します
+// 1件のみの場合と、一括送信用のエンドポイント（/logs/batch）がないサーバー（404）には、/logsに1件ずつ送信します
+func sendLogBatchHTTP(ctx context.Context, client *Client, taskID string, logs []LogRequest) error {
+	// 時刻やシーケンス番号が設定されていないログには送信時点の値を付与する
+	for i := range logs {
+		if logs[i].Timestamp.IsZero() {
+			logs[i].Timestamp = time.Now()
+		}
+		if logs[i].Sequence == 0 {
+			logs[i].Sequence = logger.NextSequence()
+		}
+		if logs[i].Source == "" {
+			logs[i].Source = LogSourceAgent
 		}
 	}
 
-	resp, err := client.httpClient.Do(req)
+	if len(logs) == 1 || (client.batchUnsupported != nil && client.batchUnsupported.Load()) {
+		return sendLogsOneByOne(ctx, client, taskID, logs)
+	}
 
-	// API呼び出しエラーの詳細をログに記録（警告レベル）
-	logAPIError("POST", url, headers, reqBody, resp, err, true)
+	logger.WithTaskIDAndComponent("api").WithField("count", len(logs)).Debug("ログを一括送信中")
 
-	if err != nil {
-		return fmt.Errorf("API呼び出しに失敗: %w", err)
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
+	// エラーログにはログ件数のみを記録する
+	err := client.do(ctx, &apiRequest{
+		method:   http.MethodPost,
+		path:     fmt.Sprintf("/api/v1/tasks/%s/logs/batch", taskID),
+		body:     LogBatchRequest{Logs: logs},
+		logBody:  map[string]int{"count": len(logs)},
+		warnOnly: true,
+	}, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		logger.WithTaskIDAndComponent("api").Info("サーバーがログの一括送信に対応していないため、1件ずつ送信します")
+		if client.batchUnsupported != nil {
+			client.batchUnsupported.Store(true)
 		}
-	}()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API呼び出しが失敗しました: %d - %s", resp.StatusCode, string(body))
+		return sendLogsOneByOne(ctx, client, taskID, logs)
 	}
+	return err
+}
 
+// sendLogsOneByOne は複数のログを/logsに1件ずつ送信します
+// 時刻・シーケンス番号・送信元・フィールドは一括送信と同じ値を送信します
+func sendLogsOneByOne(ctx context.Context, client *Client, taskID string, logs []LogRequest) error {
+	for _, log := range logs {
+		err := client.do(ctx, &apiRequest{
+			method:   http.MethodPost,
+			path:     fmt.Sprintf("/api/v1/tasks/%s/logs", taskID),
+			body:     log,
+			warnOnly: true,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AuthLevel はAPIトークンで確認できた到達範囲を表します
+type AuthLevel string
+
+const (
+	// AuthLevelUnreachable はAPIに接続できなかったことを表します
+	AuthLevelUnreachable AuthLevel = "unreachable"
+	// AuthLevelReachable はAPIに接続できたが、トークンの認証に失敗した（または判定できなかった）ことを表します
+	AuthLevelReachable AuthLevel = "reachable"
+	// AuthLevelAuthenticated はトークンは有効だが、リソースへのアクセスが許可されなかった（またはリソースが存在しなかった）ことを表します
+	AuthLevelAuthenticated AuthLevel = "authenticated"
+	// AuthLevelAuthorized はトークンが有効で、リソースへのアクセスも許可されたことを表します
+	AuthLevelAuthorized AuthLevel = "authorized"
+)
+
+// ProbeResult は認証付きエンドポイントの呼び出し結果です
+type ProbeResult struct {
+	Level AuthLevel `json:"level"`
+	URL   string    `json:"url"`
+	// StatusCode はレスポンスのステータスコードです（接続できなかった場合は0）
+	StatusCode int `json:"statusCode,omitempty"`
+	// Latency はリクエストを送信してからレスポンスヘッダーを受信するまでの時間です
+	Latency time.Duration `json:"latency"`
+	// TLSExpiry はサーバー証明書の有効期限です（HTTPSでない場合はnil）
+	TLSExpiry *time.Time `json:"tlsExpiry,omitempty"`
+	// Err は認可まで確認できなかった理由です
+	Err error `json:"-"`
+}
+
+// TokenRejected はサーバーがトークンを拒否した（401）かどうかを返します
+// 403はトークン自体は受け入れられているため、AuthLevelAuthenticatedとして扱い拒否とはみなしません
+func (r *ProbeResult) TokenRejected() bool {
+	return r.StatusCode == http.StatusUnauthorized
+}
+
+// ProbePath は認証の確認に使うエンドポイントのパスを返します
+// タスクID、セッションID、ワークスペースIDの順に、設定されているものに対応するエンドポイントを使用します
+func ProbePath(taskID, sessionID, workspaceID string) (string, error) {
+	switch {
+	case taskID != "":
+		return fmt.Sprintf("/api/v1/tasks/%s", url.PathEscape(taskID)), nil
+	case sessionID != "":
+		return fmt.Sprintf("/api/v1/sessions/%s", url.PathEscape(sessionID)), nil
+	case workspaceID != "":
+		return fmt.Sprintf("/api/v1/workspaces/%s/tasks/pending", url.PathEscape(workspaceID)), nil
+	default:
+		return "", fmt.Errorf("認証を確認するためのタスクID、セッションID、ワークスペースIDが設定されていません")
+	}
+}
+
+// ProbeAuth は設定されたトークンで認証付きエンドポイントを1回だけ呼び出し、認証・認可の状態を確認します
+func (c *Client) ProbeAuth(path string) *ProbeResult {
+	return probeAuthHTTP(c.context(), c, path)
+}
+
+// probeAuthHTTP は認証付きエンドポイントを呼び出し、結果を分類します
+// 接続の問題とトークンの問題を区別するため、再試行はしません
+func probeAuthHTTP(ctx context.Context, client *Client, path string) *ProbeResult {
+	result := &ProbeResult{Level: AuthLevelUnreachable, URL: client.baseURL + path}
+
+	start := time.Now()
+	resp, err := client.send(ctx, &apiRequest{method: http.MethodGet, path: path, silent: true})
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer closeResponse(resp)
+
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiry := resp.TLS.PeerCertificates[0].NotAfter
+		result.TLSExpiry = &expiry
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		result.Level = AuthLevelAuthorized
+		return result
+	case resp.StatusCode == http.StatusForbidden, resp.StatusCode == http.StatusNotFound:
+		// リソースが存在しない場合はトークンが受け入れられているが、アクセスできるかどうかは確認できない
+		result.Level = AuthLevelAuthenticated
+	default:
+		result.Level = AuthLevelReachable
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	result.Err = &APIError{
+		Method:     http.MethodGet,
+		URL:        result.URL,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+	return result
+}
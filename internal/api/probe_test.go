@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		level    AuthLevel
+		rejected bool
+	}{
+		{"authorized", http.StatusOK, AuthLevelAuthorized, false},
+		{"resource not found", http.StatusNotFound, AuthLevelAuthenticated, false},
+		{"token rejected", http.StatusUnauthorized, AuthLevelReachable, true},
+		{"forbidden", http.StatusForbidden, AuthLevelAuthenticated, false},
+		{"server error", http.StatusInternalServerError, AuthLevelReachable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v1/sessions/session-1", r.URL.Path)
+				assert.Equal(t, "Bearer probe-token", r.Header.Get("Authorization"))
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := &Client{baseURL: server.URL, token: "probe-token", httpClient: &http.Client{}}
+			path, err := ProbePath("", "session-1", "workspace-1")
+			require.NoError(t, err)
+
+			result := client.ProbeAuth(path)
+			assert.Equal(t, tt.level, result.Level)
+			assert.Equal(t, tt.status, result.StatusCode)
+			assert.Equal(t, tt.rejected, result.TokenRejected())
+			assert.Equal(t, tt.level != AuthLevelAuthorized, result.Err != nil)
+			assert.Nil(t, result.TLSExpiry)
+		})
+	}
+}
+
+func TestProbeAuthTLSAndUnreachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &Client{baseURL: server.URL, token: "probe-token", httpClient: server.Client()}
+	result := client.ProbeAuth("/api/v1/tasks/task-1")
+	assert.Equal(t, AuthLevelAuthorized, result.Level)
+	require.NotNil(t, result.TLSExpiry)
+	assert.Equal(t, server.Certificate().NotAfter, *result.TLSExpiry)
+	assert.Positive(t, result.Latency)
+
+	server.Close()
+	result = client.ProbeAuth("/api/v1/tasks/task-1")
+	assert.Equal(t, AuthLevelUnreachable, result.Level)
+	assert.Error(t, result.Err)
+	assert.False(t, result.TokenRejected())
+}
+
+func TestProbePath(t *testing.T) {
+	path, err := ProbePath("task/1", "session-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/tasks/task%2F1", path)
+
+	path, err = ProbePath("", "", "workspace-1")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/workspaces/workspace-1/tasks/pending", path)
+
+	_, err = ProbePath("", "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"keruta-agent/internal/logger"
+	"keruta-agent/internal/metrics"
+	"keruta-agent/internal/redact"
+	"keruta-agent/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// APIError はAPIが成功以外のステータスコードを返したことを表します
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+	// RetryAfter はレスポンスのRetry-Afterヘッダーが示す待ち時間です
+	RetryAfter time.Duration
+}
+
+// Error はエラーメッセージを返します
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API呼び出しが失敗しました: %d - %s (URL: %s)", e.StatusCode, e.Body, e.URL)
+}
+
+// apiRequest は共通のリクエスト処理に渡すリクエストの内容を表します
+type apiRequest struct {
+	method string
+	// path はbaseURLからのパスです（クエリ文字列を含む）
+	path string
+	// body はJSONとして送信するリクエストボディです
+	body interface{}
+	// newBody はJSON以外のリクエストボディを作成します（指定時はbodyより優先されます）
+	// 再試行時に同じ内容を送り直せるよう、送信のたびに新しいReaderを作成します
+	newBody func() (io.Reader, error)
+	// contentLength はnewBodyのサイズです（0以下の場合は不明としてチャンク転送します）
+	contentLength int64
+	contentType   string
+	// headers は追加で付与するリクエストヘッダーです
+	headers map[string]string
+	// logBody はエラー時にログへ記録するボディです。nilの場合はbodyを記録します
+	logBody interface{}
+	// warnOnly は失敗を警告レベルでログに記録します
+	warnOnly bool
+	// silent は失敗をログに記録しません（ポーリングなど失敗が想定される呼び出し用）
+	silent bool
+}
+
+// send はリクエストを作成して送信し、レスポンスを返します
+// 認証ヘッダーの付与、JSONのエンコードと秘匿情報のマスク、エラーのログ記録、所要時間のメトリクスとトレースの記録を共通で行います
+// ステータスコードの確認は呼び出し側で行い、レスポンスボディは呼び出し側でクローズする必要があります
+func (c *Client) send(ctx context.Context, r *apiRequest) (*http.Response, error) {
+	url := c.baseURL + r.path
+
+	var body io.Reader
+	if r.newBody != nil {
+		var err error
+		if body, err = r.newBody(); err != nil {
+			return nil, err
+		}
+	}
+	contentType := r.contentType
+	if body == nil && r.body != nil {
+		jsonData, err := json.Marshal(r.body)
+		if err != nil {
+			return nil, fmt.Errorf("リクエストボディのマーシャルに失敗: %w", err)
+		}
+		// ログやステータスメッセージに含まれる秘匿情報はワークスペースの外に送信しない
+		redacted, err := redact.JSON(jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("リクエストボディのマスクに失敗: %w", err)
+		}
+		body = bytes.NewReader(redacted)
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	endpoint := metrics.Endpoint(r.path)
+	ctx, span := tracing.StartClient(ctx, r.method+" "+endpoint,
+		attribute.String("http.request.method", r.method),
+		attribute.String("url.template", endpoint),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, r.method, url, body)
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		tracing.End(span, err)
+		return nil, fmt.Errorf("リクエストの作成に失敗: %w", err)
+	}
+	// APIサーバー側のトレースと関連付けられるようにtraceparentを送信する
+	tracing.Inject(ctx, req.Header)
+
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+	if r.newBody != nil && r.contentLength > 0 {
+		req.ContentLength = r.contentLength
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// リクエストヘッダーを収集
+	headers := make(map[string]string)
+	for k, v := range req.Header {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	metrics.ObserveAPIRequest(r.method, r.path, statusCode, time.Since(start))
+	tracing.EndHTTP(span, statusCode, err)
+
+	// API呼び出しエラーの詳細をログに記録
+	if !r.silent {
+		logBody := r.logBody
+		if logBody == nil {
+			logBody = r.body
+		}
+		logAPIError(r.method, url, headers, logBody, resp, err, r.warnOnly)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("API呼び出しに失敗: %w", err)
+	}
+	return resp, nil
+}
+
+// do はリクエストを送信し、2xx以外のステータスコードを*APIErrorとして返します
+// outがnilでない場合はレスポンスボディをJSONとしてデコードします
+// ネットワークエラーや5xx・429のレスポンスはクライアントの再試行方針に従って再試行します
+func (c *Client) do(ctx context.Context, r *apiRequest, out interface{}) error {
+	return c.retry.Do(ctx, r.method+" "+r.path, func(ctx context.Context) error {
+		return c.doOnce(ctx, r, out)
+	})
+}
+
+// doOnce はリクエストを1回だけ送信します
+func (c *Client) doOnce(ctx context.Context, r *apiRequest, out interface{}) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{
+			Method:     r.method,
+			URL:        resp.Request.URL.String(),
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("レスポンスのデコードに失敗: %w (URL: %s)", err, resp.Request.URL.String())
+	}
+	return nil
+}
+
+// closeResponse はレスポンスボディをクローズします
+func closeResponse(resp *http.Response) {
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		logger.WithTaskIDAndComponent("api").WithError(closeErr).Warning("レスポンスボディのクローズに失敗しました")
+	}
+}
@@ -1,8 +1,15 @@
 package api
 
 import (
-	"fmt"
-	"strings"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
 	"time"
 
 	"keruta-agent/internal/config"
@@ -11,73 +18,160 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// defaultRetryBaseDelay は最初の再試行までの待ち時間です
+	defaultRetryBaseDelay = 1 * time.Second
+	// defaultRetryMaxDelay は再試行までの待ち時間の上限です（Retry-Afterには適用しません）
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
 // RetryableFunc は再試行可能な関数の型です
-type RetryableFunc func() error
+type RetryableFunc func(ctx context.Context) error
 
-// RetryWithBackoff は指定された関数を指数バックオフで再試行します
-func RetryWithBackoff(operation string, fn RetryableFunc) error {
-	retryCount := config.GlobalConfig.ErrorHandling.RetryCount
-	var err error
+// RetryPolicy はAPI呼び出しの再試行方針を表します
+type RetryPolicy struct {
+	// MaxAttempts は最大試行回数です（1以下の場合は再試行しません）
+	MaxAttempts int
+	// BaseDelay は最初の再試行までの待ち時間です。以降は試行ごとに倍になります
+	BaseDelay time.Duration
+	// MaxDelay は再試行までの待ち時間の上限です
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy は設定の ErrorHandling.RetryCount に従う再試行方針を返します
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: config.GetRetryCount(),
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    defaultRetryMaxDelay,
+	}
+}
+
+// RetryWithBackoff は指定された関数をデフォルトの再試行方針で再試行します
+func RetryWithBackoff(ctx context.Context, operation string, fn RetryableFunc) error {
+	return DefaultRetryPolicy().Do(ctx, operation, fn)
+}
 
-	for i := 0; i < retryCount; i++ {
-		err = fn()
+// Do は指定された関数を実行し、再試行可能なエラーの場合はジッター付きの指数バックオフで再試行します
+// ctxが終了した場合は待機を中断し、最後のエラーを返します
+func (p RetryPolicy) Do(ctx context.Context, operation string, fn RetryableFunc) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = fn(ctx)
 		if err == nil {
 			return nil
 		}
 
-		// 最後の試行ではエラーを返す
-		if i == retryCount-1 {
+		// 最後の試行、または再試行しても解決しないエラーの場合はエラーを返す
+		if i == attempts-1 || ctx.Err() != nil || !IsRetryable(err) {
 			return err
 		}
 
-		// 接続エラーの場合のみ再試行
-		if isConnectionError(err) {
-			// 指数バックオフ: 1秒、2秒、4秒...
-			waitTime := time.Duration(1<<uint(i)) * time.Second
-			logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
-				"operation": operation,
-				"attempt":   i + 1,
-				"maxRetry":  retryCount,
-				"waitTime":  waitTime,
-				"error":     err.Error(),
-			}).Warning("API呼び出しに失敗しました。再試行します")
-			time.Sleep(waitTime)
-		} else {
-			// 接続エラー以外はすぐに失敗
+		waitTime := p.backoff(i, err)
+		logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
+			"operation": operation,
+			"attempt":   i + 1,
+			"maxRetry":  attempts,
+			"waitTime":  waitTime,
+			"error":     err.Error(),
+		}).Warning("API呼び出しに失敗しました。再試行します")
+
+		timer := time.NewTimer(waitTime)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
 			return err
+		case <-timer.C:
 		}
 	}
 
 	return err
 }
 
-// isConnectionError は与えられたエラーが接続エラーかどうかを判定します
-func isConnectionError(err error) bool {
-	if err == nil {
+// backoff はi回目の失敗後の待ち時間を返します
+// 指数バックオフの上半分からランダムに選び、Retry-Afterが指定されている場合はそれ以上待機します
+func (p RetryPolicy) backoff(i int, err error) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	delay := base
+	for j := 0; j < i && delay < maxDelay; j++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	half := delay / 2
+	delay = half + time.Duration(rand.Int63n(int64(half)+1))
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+	return delay
+}
+
+// IsRetryable はエラーが再試行によって解決する可能性があるかどうかを判定します
+// ネットワークエラー、5xx、429のレスポンスが対象です。コンテキストのキャンセルは対象外です
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
 		return false
 	}
 
-	errMsg := err.Error()
-	// 接続エラーに関連する文字列をチェック
-	connectionErrors := []string{
-		"connection refused",
-		"connect: connection refused",
-		"i/o timeout",
-		"no such host",
-		"network is unreachable",
-		"connection reset by peer",
-		"dial tcp",
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
 	}
 
-	for _, msg := range connectionErrors {
-		if contains(errMsg, msg) {
-			return true
-		}
+	return isConnectionError(err)
+}
+
+// isConnectionError は与えられたエラーが接続エラーかどうかを判定します
+func isConnectionError(err error) bool {
+	// *url.Error自体もnet.Errorを実装しているため、内側のエラーで判定する
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
 	}
-	return false
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		// タイムアウトや接続拒否、名前解決の失敗などを含む
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
 }
 
-// contains は文字列に部分文字列が含まれているかどうかを判定します
-func contains(s, substr string) bool {
-	return fmt.Sprintf("%s", s) != "" && strings.Contains(s, substr)
+// parseRetryAfter はRetry-Afterヘッダー（秒数またはHTTP日付）を待ち時間に変換します
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryTestClient(url string, attempts int) *Client {
+	return &Client{
+		baseURL:    url,
+		token:      "retry-token",
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		retry: RetryPolicy{
+			MaxAttempts: attempts,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    5 * time.Millisecond,
+		},
+	}
+}
+
+func TestClientRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	err := newRetryTestClient(server.URL, 3).UpdateTaskStatus("retry-task", TaskStatusCompleted, "done", 100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := newRetryTestClient(server.URL, 3).UpdateTaskStatus("retry-task", TaskStatusCompleted, "done", 100, "")
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClientRetryBoundedByMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := newRetryTestClient(server.URL, 2).SendLog("retry-task", "INFO", "message")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClientRetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	err := newRetryTestClient(url, 2).SendLog("retry-task", "INFO", "message")
+	require.Error(t, err)
+	assert.True(t, IsRetryable(err))
+}
+
+func TestRetryPolicyStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	calls := 0
+	start := time.Now()
+	err := policy.Do(ctx, "test", func(context.Context) error {
+		calls++
+		cancel()
+		return &APIError{StatusCode: http.StatusServiceUnavailable}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicyHonoursRetryAfter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	delay := policy.backoff(0, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second})
+	assert.Equal(t, 3*time.Second, delay)
+
+	delay = policy.backoff(0, &APIError{StatusCode: http.StatusServiceUnavailable})
+	assert.LessOrEqual(t, delay, time.Millisecond)
+}
+
+func TestRetryPolicyBackoffIsBounded(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	for i := 0; i < 10; i++ {
+		delay := policy.backoff(i, fmt.Errorf("dial tcp: %w", errors.New("refused")))
+		assert.LessOrEqual(t, delay, 4*time.Second)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&APIError{StatusCode: http.StatusBadGateway}))
+	assert.True(t, IsRetryable(&APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, IsRetryable(&APIError{StatusCode: http.StatusNotFound}))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(errors.New("connection refused")))
+	assert.False(t, IsRetryable(nil))
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxUploadResumes は分割アップロードが失敗したときにオフセットを確認して再開する最大回数です
+	maxUploadResumes = 3
+	// chunkChecksumHeader はチャンクのSHA-256チェックサムを送るヘッダーです
+	chunkChecksumHeader = "X-Chunk-SHA256"
+	// uploadStateDirName は未完了のアップロードを記録する状態ディレクトリ内のディレクトリ名です
+	uploadStateDirName = "uploads"
+)
+
+// errChunkedUploadUnsupported はサーバーが分割アップロードに対応していないことを表します
+var errChunkedUploadUnsupported = errors.New("サーバーが分割アップロードに対応していません")
+
+// uploadInitRequest は分割アップロードの開始リクエストを表します
+type uploadInitRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	ChunkSize   int64  `json:"chunkSize"`
+}
+
+// uploadSession はサーバー側の分割アップロードの状態を表します
+type uploadSession struct {
+	UploadID string `json:"uploadId"`
+	// Offset はサーバーが受信済みのバイト数です
+	Offset int64 `json:"offset"`
+}
+
+// uploadCompleteRequest は分割アップロードの完了リクエストを表します
+// サーバーは受信した内容のSHA-256チェックサムを検証します
+type uploadCompleteRequest struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// uploadState は未完了のアップロードを再開するために状態ディレクトリに保存する情報です
+type uploadState struct {
+	TaskID   string `json:"taskId"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+	UploadID string `json:"uploadId"`
+}
+
+// artifactChunkSize は分割アップロードの1チャンクのサイズを返します
+func (c *Client) artifactChunkSize() int64 {
+	if c.chunkSize > 0 {
+		return c.chunkSize
+	}
+	return config.GetArtifactsChunkSize()
+}
+
+// uploadArtifactChunked は成果物を分割してアップロードします
+//  1. POST .../artifacts/uploads でアップロードIDを発行する（前回の未完了のアップロードがあればそれを再開する）
+//  2. PUT .../artifacts/uploads/{uploadId} でチャンクを順に送信する（Content-Rangeでオフセットを指定）
+//  3. POST .../artifacts/uploads/{uploadId}/complete でサイズとチェックサムを送り、サーバーが検証する
+//
+// チャンクの送信が再試行しても失敗した場合は、サーバーが受信済みのオフセットを確認してそこから再開します
+func uploadArtifactChunked(ctx context.Context, client *Client, taskID string, source *uploadSource, description string) error {
+	basePath := fmt.Sprintf("/api/v1/tasks/%s/artifacts/uploads", taskID)
+	log := logger.WithTaskIDAndComponent("api").WithFields(logrus.Fields{
+		"file": source.name,
+		"size": source.size,
+	})
+
+	session, err := resumeUploadSession(ctx, client, basePath, taskID, source)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		session = &uploadSession{}
+		err := client.do(ctx, &apiRequest{
+			method: http.MethodPost,
+			path:   basePath,
+			body: uploadInitRequest{
+				Name:        source.name,
+				Description: description,
+				Size:        source.size,
+				SHA256:      source.sha256,
+				ChunkSize:   client.artifactChunkSize(),
+			},
+			warnOnly: true,
+		}, session)
+		if err != nil {
+			if isUnsupportedEndpoint(err) {
+				return errChunkedUploadUnsupported
+			}
+			return fmt.Errorf("分割アップロードの開始に失敗: %w", err)
+		}
+		if session.UploadID == "" {
+			return fmt.Errorf("分割アップロードの開始に失敗: アップロードIDが返されませんでした")
+		}
+		saveUploadState(taskID, source, session.UploadID)
+	} else {
+		log.WithFields(logrus.Fields{
+			"uploadId": session.UploadID,
+			"offset":   session.Offset,
+		}).Info("未完了のアップロードを再開します")
+	}
+	sessionPath := basePath + "/" + url.PathEscape(session.UploadID)
+
+	file, err := os.Open(source.path)
+	if err != nil {
+		return fmt.Errorf("ファイルのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	for resumes := 0; ; resumes++ {
+		err = sendUploadChunks(ctx, client, sessionPath, file, source.size, session)
+		if err == nil {
+			break
+		}
+		if resumes >= maxUploadResumes || ctx.Err() != nil || !IsRetryable(err) {
+			return fmt.Errorf("チャンクの送信に失敗: %w", err)
+		}
+
+		// 送信済みのオフセットをサーバーに確認して、そこから再開する
+		status := &uploadSession{}
+		if statusErr := client.do(ctx, &apiRequest{method: http.MethodGet, path: sessionPath, warnOnly: true}, status); statusErr != nil {
+			return fmt.Errorf("チャンクの送信に失敗: %w", err)
+		}
+		session.Offset = status.Offset
+		log.WithError(err).WithFields(logrus.Fields{
+			"uploadId": session.UploadID,
+			"offset":   session.Offset,
+		}).Warning("チャンクの送信に失敗しました。受信済みのオフセットから再開します")
+	}
+
+	err = client.do(ctx, &apiRequest{
+		method:   http.MethodPost,
+		path:     sessionPath + "/complete",
+		body:     uploadCompleteRequest{Size: source.size, SHA256: source.sha256},
+		warnOnly: true,
+	}, nil)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusConflict || apiErr.StatusCode == http.StatusUnprocessableEntity) {
+			// チェックサムが一致しないアップロードは再開しても成功しないため、記録を破棄する
+			removeUploadState(taskID, source)
+			return fmt.Errorf("サーバーでのチェックサムの検証に失敗: %w", err)
+		}
+		return fmt.Errorf("分割アップロードの完了に失敗: %w", err)
+	}
+	removeUploadState(taskID, source)
+	return nil
+}
+
+// resumeUploadSession は前回の未完了のアップロードがあれば、サーバーから受信済みのオフセットを取得します
+// 再開できるアップロードがない場合はnilを返します
+func resumeUploadSession(ctx context.Context, client *Client, basePath, taskID string, source *uploadSource) (*uploadSession, error) {
+	state := loadUploadState(taskID, source)
+	if state == nil {
+		return nil, nil
+	}
+
+	session := &uploadSession{}
+	err := client.do(ctx, &apiRequest{
+		method: http.MethodGet,
+		path:   basePath + "/" + url.PathEscape(state.UploadID),
+		silent: true,
+	}, session)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
+			// 期限切れなどでサーバーにアップロードが残っていない場合は最初からやり直す
+			removeUploadState(taskID, source)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("アップロード状態の取得に失敗: %w", err)
+	}
+	session.UploadID = state.UploadID
+	return session, nil
+}
+
+// sendUploadChunks はsession.Offsetから最後までチャンクを順に送信し、送信済みのオフセットを更新します
+func sendUploadChunks(ctx context.Context, client *Client, sessionPath string, file io.ReaderAt, size int64, session *uploadSession) error {
+	chunkSize := client.artifactChunkSize()
+	for session.Offset < size {
+		start := session.Offset
+		length := chunkSize
+		if remaining := size - start; remaining < length {
+			length = remaining
+		}
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, io.NewSectionReader(file, start, length)); err != nil {
+			return fmt.Errorf("ファイルの読み込みに失敗: %w", err)
+		}
+
+		next := &uploadSession{}
+		err := client.do(ctx, &apiRequest{
+			method: http.MethodPut,
+			path:   sessionPath,
+			newBody: func() (io.Reader, error) {
+				return io.NewSectionReader(file, start, length), nil
+			},
+			contentLength: length,
+			contentType:   "application/octet-stream",
+			headers: map[string]string{
+				"Content-Range":     fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size),
+				chunkChecksumHeader: hex.EncodeToString(hash.Sum(nil)),
+			},
+			logBody:  map[string]int64{"offset": start, "length": length},
+			warnOnly: true,
+		}, next)
+		if err != nil {
+			return err
+		}
+
+		if next.Offset <= start {
+			// オフセットを返さないサーバーは送信したチャンクをすべて受信したものとみなす
+			next.Offset = start + length
+		}
+		session.Offset = next.Offset
+	}
+	return nil
+}
+
+// isUnsupportedEndpoint はエンドポイントが存在しないことを表すエラーかどうかを判定します
+func isUnsupportedEndpoint(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// uploadStatePath は未完了のアップロードを記録するファイルのパスを返します
+// 同じタスク・ファイル名・内容のアップロードは同じファイルに記録されます
+func uploadStatePath(taskID string, source *uploadSource) string {
+	key := sha256.Sum256([]byte(taskID + "\x00" + source.name + "\x00" + source.sha256))
+	return filepath.Join(config.GetStateDir(), uploadStateDirName, hex.EncodeToString(key[:16])+".json")
+}
+
+// loadUploadState は未完了のアップロードの記録を読み込みます（記録がない場合はnil）
+func loadUploadState(taskID string, source *uploadSource) *uploadState {
+	data, err := os.ReadFile(uploadStatePath(taskID, source))
+	if err != nil {
+		return nil
+	}
+	var state uploadState
+	if err := json.Unmarshal(data, &state); err != nil || state.UploadID == "" ||
+		state.TaskID != taskID || state.Name != source.name || state.Size != source.size || state.SHA256 != source.sha256 {
+		return nil
+	}
+	return &state
+}
+
+// saveUploadState は未完了のアップロードを記録します
+// 記録に失敗してもアップロードは継続します（中断した場合に最初からやり直すだけです）
+func saveUploadState(taskID string, source *uploadSource, uploadID string) {
+	path := uploadStatePath(taskID, source)
+	data, err := json.Marshal(uploadState{
+		TaskID:   taskID,
+		Name:     source.name,
+		Size:     source.size,
+		SHA256:   source.sha256,
+		UploadID: uploadID,
+	})
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+			err = os.WriteFile(path, data, 0o600)
+		}
+	}
+	if err != nil {
+		logger.WithTaskIDAndComponent("api").WithError(err).Warning("アップロード状態の保存に失敗しました")
+	}
+}
+
+// removeUploadState は未完了のアップロードの記録を削除します
+func removeUploadState(taskID string, source *uploadSource) {
+	if err := os.Remove(uploadStatePath(taskID, source)); err != nil && !os.IsNotExist(err) {
+		logger.WithTaskIDAndComponent("api").WithError(err).Warning("アップロード状態の削除に失敗しました")
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUploadServer は分割アップロードに対応したテスト用のサーバーです
+type fakeUploadServer struct {
+	t  *testing.T
+	mu sync.Mutex
+	// data は受信した内容です
+	data []byte
+	// inits は分割アップロードの開始リクエストの回数です
+	inits int
+	// chunks は受信したチャンクの数です
+	chunks int
+	// failChunk はtrueを返したチャンクの送信を失敗させます
+	failChunk func(n int) (int, bool)
+	completed string
+}
+
+func (s *fakeUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	const base = "/api/v1/tasks/upload-task/artifacts/uploads"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == base:
+		var req uploadInitRequest
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&req))
+		s.inits++
+		s.data = nil
+		json.NewEncoder(w).Encode(uploadSession{UploadID: fmt.Sprintf("up-%d", s.inits)})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, base+"/"):
+		json.NewEncoder(w).Encode(uploadSession{Offset: int64(len(s.data))})
+	case r.Method == http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		require.NoError(s.t, err)
+		if s.failChunk != nil {
+			if status, fail := s.failChunk(s.chunks); fail {
+				s.chunks++
+				w.WriteHeader(status)
+				return
+			}
+		}
+		s.chunks++
+
+		var start, end, total int64
+		_, err = fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+		require.NoError(s.t, err)
+		assert.Equal(s.t, int64(len(s.data)), start)
+		assert.Equal(s.t, int64(len(body)), r.ContentLength)
+		sum := sha256.Sum256(body)
+		assert.Equal(s.t, hex.EncodeToString(sum[:]), r.Header.Get(chunkChecksumHeader))
+		s.data = append(s.data, body...)
+		json.NewEncoder(w).Encode(uploadSession{Offset: int64(len(s.data))})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/complete"):
+		var req uploadCompleteRequest
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&req))
+		sum := sha256.Sum256(s.data)
+		if req.SHA256 != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		s.completed = req.SHA256
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeUploadFile(t *testing.T, size int) (string, []byte) {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	content[0] = 0 // バイナリとして扱わせる
+	path := filepath.Join(t.TempDir(), "data.bin")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	return path, content
+}
+
+func TestUploadArtifactSendsChecksum(t *testing.T) {
+	t.Setenv("KERUTA_STATE_DIR", t.TempDir())
+	content := []byte("checksum content\n")
+	sum := sha256.Sum256(content)
+	expected := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, expected, r.Header.Get(checksumHeader))
+		require.NoError(t, r.ParseMultipartForm(32<<20))
+		assert.Equal(t, expected, r.FormValue("sha256"))
+		assert.Equal(t, fmt.Sprint(len(content)), r.FormValue("size"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	client := &Client{baseURL: server.URL, httpClient: &http.Client{}}
+	require.NoError(t, client.UploadArtifact("upload-task", path, ""))
+}
+
+func TestUploadArtifactChunked(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("KERUTA_STATE_DIR", stateDir)
+	path, content := writeUploadFile(t, 2500)
+
+	// 2つ目のチャンクの送信を1回失敗させ、受信済みのオフセットから再開させる
+	fake := &fakeUploadServer{t: t, failChunk: func(n int) (int, bool) {
+		return http.StatusServiceUnavailable, n == 1
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := &Client{baseURL: server.URL, httpClient: &http.Client{}, chunkSize: 1000}
+	require.NoError(t, client.UploadArtifact("upload-task", path, "大きなファイル"))
+
+	assert.Equal(t, content, fake.data)
+	assert.Equal(t, 1, fake.inits)
+	assert.Equal(t, 4, fake.chunks)
+	assert.NotEmpty(t, fake.completed)
+
+	// 完了したアップロードの記録は削除される
+	entries, _ := os.ReadDir(filepath.Join(stateDir, uploadStateDirName))
+	assert.Empty(t, entries)
+}
+
+func TestUploadArtifactChunkedResumesAfterRestart(t *testing.T) {
+	t.Setenv("KERUTA_STATE_DIR", t.TempDir())
+	path, content := writeUploadFile(t, 2500)
+
+	// 最初のアップロードは2つ目のチャンクで再開できないエラーにより中断する
+	fake := &fakeUploadServer{t: t, failChunk: func(n int) (int, bool) {
+		return http.StatusBadRequest, n == 1
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := &Client{baseURL: server.URL, httpClient: &http.Client{}, chunkSize: 1000}
+	require.Error(t, client.UploadArtifact("upload-task", path, ""))
+	assert.Equal(t, 1000, len(fake.data))
+
+	// 次のアップロードは記録したアップロードIDで受信済みのオフセットから続ける
+	require.NoError(t, client.UploadArtifact("upload-task", path, ""))
+	assert.Equal(t, content, fake.data)
+	assert.Equal(t, 1, fake.inits)
+	assert.Equal(t, 4, fake.chunks)
+}
+
+func TestUploadArtifactFallsBackToMultipart(t *testing.T) {
+	t.Setenv("KERUTA_STATE_DIR", t.TempDir())
+	path, content := writeUploadFile(t, 2500)
+
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tasks/upload-task/artifacts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.NoError(t, r.ParseMultipartForm(32<<20))
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		received, err = io.ReadAll(file)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &Client{baseURL: server.URL, httpClient: &http.Client{}, chunkSize: 1000}
+	require.NoError(t, client.UploadArtifact("upload-task", path, ""))
+	assert.Equal(t, content, received)
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+	"keruta-agent/pkg/artifacts"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// artifact add コマンドのフラグ
+	artifactDescription string
+)
+
+// artifactCmd はタスクの成果物を管理するコマンドです
+var artifactCmd = &cobra.Command{
+	Use:   "artifact",
+	Short: "タスクの成果物を管理",
+	Long: `タスクの成果物をアップロード・一覧表示・削除します。
+
+終了コード:
+  0  正常終了
+  1  予期しないエラー
+  2  引数・タスクIDの指定誤り、ファイルの検証エラー
+  3  keruta APIの呼び出しに失敗`,
+}
+
+// artifactAddCmd は成果物をアップロードするコマンドです
+var artifactAddCmd = &cobra.Command{
+	Use:   "add <file>...",
+	Short: "成果物をアップロード",
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return newUsageError("アップロードするファイルを指定してください")
+		}
+		return nil
+	},
+	RunE:    runArtifactAdd,
+	Example: `  keruta artifact add ./output/report.pdf --description "月次レポート"`,
+}
+
+// artifactListCmd はサーバーに保存されている成果物を一覧表示するコマンドです
+var artifactListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "成果物を一覧表示",
+	Args:    lifecycleNoArgs,
+	RunE:    runArtifactList,
+	Example: `  keruta artifact list`,
+}
+
+// artifactRemoveCmd は成果物を削除するコマンドです
+var artifactRemoveCmd = &cobra.Command{
+	Use:   "remove <id|name>",
+	Short: "成果物を削除",
+	Long:  `成果物をIDまたはファイル名で指定して削除します。同じ名前の成果物が複数ある場合はIDで指定してください。`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return newUsageError("削除する成果物のIDまたはファイル名を1つ指定してください")
+		}
+		return nil
+	},
+	RunE:    runArtifactRemove,
+	Example: `  keruta artifact remove report.pdf`,
+}
+
+func runArtifactAdd(cmd *cobra.Command, args []string) error {
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	// すべてのファイルを検証してからアップロードする
+	manager := artifacts.NewManager()
+	for _, path := range args {
+		if err := manager.ValidateArtifact(path); err != nil {
+			return newUsageError("%v", err)
+		}
+	}
+
+	apiClient := api.NewClient()
+	for _, path := range args {
+		description := artifactDescription
+		if description == "" {
+			description = manager.GetArtifactDescription(artifacts.Artifact{Path: path, Name: filepath.Base(path)})
+		}
+		if err := apiClient.UploadArtifact(taskID, path, description); err != nil {
+			return handleLifecycleAPIError(cmd, fmt.Errorf("artifact upload failed (%s): %w", path, err))
+		}
+		logger.WithTaskID().WithFields(logrus.Fields{
+			"file":        path,
+			"description": description,
+		}).Info("成果物をアップロードしました")
+	}
+	return nil
+}
+
+func runArtifactList(cmd *cobra.Command, _ []string) error {
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	list, err := api.NewClient().ListArtifacts(taskID)
+	if err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("artifact list failed: %w", err))
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSIZE\tDESCRIPTION")
+	for _, artifact := range list {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", artifact.ID, artifact.Name, artifact.Size, artifact.Description)
+	}
+	return w.Flush()
+}
+
+func runArtifactRemove(cmd *cobra.Command, args []string) error {
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	apiClient := api.NewClient()
+	list, err := apiClient.ListArtifacts(taskID)
+	if err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("artifact list failed: %w", err))
+	}
+
+	artifact, err := findArtifact(list, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := apiClient.DeleteArtifact(taskID, artifact.ID); err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("artifact removal failed: %w", err))
+	}
+
+	logger.WithTaskID().WithFields(logrus.Fields{
+		"artifact_id": artifact.ID,
+		"name":        artifact.Name,
+	}).Info("成果物を削除しました")
+	return nil
+}
+
+// findArtifact はIDまたはファイル名に一致する成果物を探します
+// IDが一致するものを優先し、ファイル名が複数の成果物に一致する場合はエラーを返します
+func findArtifact(list []api.Artifact, ref string) (*api.Artifact, error) {
+	for i := range list {
+		if list[i].ID == ref {
+			return &list[i], nil
+		}
+	}
+
+	var matches []*api.Artifact
+	for i := range list {
+		if list[i].Name == ref || list[i].Name == filepath.Base(ref) {
+			matches = append(matches, &list[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, newUsageError("成果物が見つかりません: %s", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, newUsageError("%sに一致する成果物が%d件あります。IDで指定してください", ref, len(matches))
+	}
+}
+
+func init() {
+	artifactAddCmd.Flags().StringVar(&artifactDescription, "description", "", "成果物の説明（省略時はファイルの種類から生成）")
+	for _, cmd := range []*cobra.Command{artifactAddCmd, artifactListCmd, artifactRemoveCmd} {
+		cmd.Flags().BoolVar(&noFailOnAPIError, "no-fail-on-api-error", false, "API呼び出しに失敗しても終了コード0で終了する（環境変数KERUTA_NO_FAIL_ON_API_ERRORでも指定可能）")
+	}
+
+	artifactCmd.AddCommand(artifactAddCmd)
+	artifactCmd.AddCommand(artifactListCmd)
+	artifactCmd.AddCommand(artifactRemoveCmd)
+}
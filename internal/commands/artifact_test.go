@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/pkg/artifacts"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupArtifactTest(t *testing.T, handler http.HandlerFunc) {
+	setupLifecycleTest(t, handler)
+	config.GlobalConfig.Artifacts.MaxSize = 1024
+	t.Cleanup(func() {
+		artifactDescription = ""
+	})
+}
+
+func TestRunArtifactAdd(t *testing.T) {
+	var descriptions []string
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/tasks/lifecycle-task-123/artifacts", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		descriptions = append(descriptions, r.FormValue("description"))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.pdf")
+	notes := filepath.Join(dir, "notes.md")
+	require.NoError(t, os.WriteFile(report, []byte("pdf"), 0644))
+	require.NoError(t, os.WriteFile(notes, []byte("# notes"), 0644))
+
+	require.NoError(t, runArtifactAdd(artifactAddCmd, []string{report}))
+	artifactDescription = "月次レポート"
+	require.NoError(t, runArtifactAdd(artifactAddCmd, []string{notes}))
+
+	assert.Equal(t, []string{"PDFドキュメント", "月次レポート"}, descriptions)
+}
+
+func TestRunArtifactAddValidation(t *testing.T) {
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("検証に失敗したファイルはアップロードしない")
+	})
+
+	large := filepath.Join(t.TempDir(), "large.bin")
+	require.NoError(t, os.WriteFile(large, make([]byte, 2048), 0644))
+
+	err := runArtifactAdd(artifactAddCmd, []string{large})
+	assert.Equal(t, ExitCodeUsageError, ExitCode(err))
+
+	err = runArtifactAdd(artifactAddCmd, []string{filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Equal(t, ExitCodeUsageError, ExitCode(err))
+}
+
+func TestRunArtifactList(t *testing.T) {
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		json.NewEncoder(w).Encode([]api.Artifact{
+			{ID: "a-1", Name: "report.pdf", Size: 3, Description: "月次レポート"},
+		})
+	})
+
+	var out bytes.Buffer
+	artifactListCmd.SetOut(&out)
+	t.Cleanup(func() { artifactListCmd.SetOut(nil) })
+
+	require.NoError(t, runArtifactList(artifactListCmd, nil))
+	assert.Contains(t, out.String(), "ID")
+	assert.Contains(t, out.String(), "a-1")
+	assert.Contains(t, out.String(), "report.pdf")
+	assert.Contains(t, out.String(), "月次レポート")
+}
+
+func TestRunArtifactRemove(t *testing.T) {
+	var deleted []string
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode([]api.Artifact{
+				{ID: "a-1", Name: "report.pdf"},
+				{ID: "a-2", Name: "notes.md"},
+				{ID: "a-3", Name: "notes.md"},
+			})
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	require.NoError(t, runArtifactRemove(artifactRemoveCmd, []string{"./output/report.pdf"}))
+	require.NoError(t, runArtifactRemove(artifactRemoveCmd, []string{"a-3"}))
+	assert.Equal(t, []string{
+		"/api/v1/tasks/lifecycle-task-123/artifacts/a-1",
+		"/api/v1/tasks/lifecycle-task-123/artifacts/a-3",
+	}, deleted)
+
+	// 名前が複数の成果物に一致する場合や見つからない場合は削除しない
+	assert.Equal(t, ExitCodeUsageError, ExitCode(runArtifactRemove(artifactRemoveCmd, []string{"notes.md"})))
+	assert.Equal(t, ExitCodeUsageError, ExitCode(runArtifactRemove(artifactRemoveCmd, []string{"missing.txt"})))
+	assert.Len(t, deleted, 2)
+}
+
+func TestRunArtifactListAPIError(t *testing.T) {
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	err := runArtifactList(artifactListCmd, nil)
+	assert.Equal(t, ExitCodeAPIError, ExitCode(err))
+	assert.Equal(t, ExitCodeAPIError, ExitCode(runArtifactRemove(artifactRemoveCmd, []string{"a-1"})))
+
+	// --no-fail-on-api-error 指定時は一覧表示・削除も正常終了扱い
+	noFailOnAPIError = true
+	assert.NoError(t, runArtifactList(artifactListCmd, nil))
+	assert.NoError(t, runArtifactRemove(artifactRemoveCmd, []string{"a-1"}))
+}
+
+func TestUploadTaskArtifacts(t *testing.T) {
+	var (
+		uploaded []string
+		calls    int
+	)
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		_, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		calls++
+		// 2件目のアップロードは失敗させる
+		if calls == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		uploaded = append(uploaded, header.Filename+":"+r.FormValue("description"))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	directory := config.GlobalConfig.Artifacts.Directory
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "a.md"), []byte("# a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "b.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "c.log"), []byte("log"), 0644))
+
+	err := uploadTaskArtifacts(api.NewClient(), &api.Task{ID: "lifecycle-task-123"}, logrus.NewEntry(logrus.New()))
+
+	// 失敗したファイルがあっても残りはアップロードし、失敗を報告する
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 3")
+	assert.Equal(t, []string{"a.md:テキストファイル", "c.log:ログファイル"}, uploaded)
+
+	// 次のタスクで再度アップロードしないよう、ディレクトリは空になる
+	entries, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.DirExists(t, filepath.Join(directory+".archive", "lifecycle-task-123"))
+}
+
+func TestUploadTaskArtifactsWithTaskFilter(t *testing.T) {
+	var uploaded []string
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		_, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		uploaded = append(uploaded, header.Filename)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	directory := config.GlobalConfig.Artifacts.Directory
+	require.NoError(t, os.MkdirAll(filepath.Join(directory, "node_modules", "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "node_modules", "pkg", "index.md"), []byte("dep"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "report.md"), []byte("# report"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "debug.log"), []byte("log"), 0644))
+
+	task := &api.Task{ID: "lifecycle-task-123", Parameters: map[string]interface{}{"artifacts_exclude": "*.log"}}
+	require.NoError(t, uploadTaskArtifacts(api.NewClient(), task, logrus.NewEntry(logrus.New())))
+
+	// 依存パッケージとタスクで除外したファイルはアップロードしない
+	assert.Equal(t, []string{"report.md"}, uploaded)
+}
+
+func TestUploadTaskArtifactsBundle(t *testing.T) {
+	var (
+		uploaded    []string
+		description string
+		bundle      []byte
+	)
+	setupArtifactTest(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		uploaded = append(uploaded, header.Filename)
+		description = r.FormValue("description")
+		bundle, err = io.ReadAll(file)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	directory := config.GlobalConfig.Artifacts.Directory
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "a.md"), []byte("# a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "b.json"), []byte("{}"), 0644))
+
+	task := &api.Task{ID: "lifecycle-task-123", Parameters: map[string]interface{}{
+		"artifacts_mode":          "bundle",
+		"artifacts_bundle_format": "zip",
+	}}
+	require.NoError(t, uploadTaskArtifacts(api.NewClient(), task, logrus.NewEntry(logrus.New())))
+
+	// 成果物は1つのアーカイブにまとめてアップロードされる
+	assert.Equal(t, []string{"artifacts-lifecycle-task-123.zip"}, uploaded)
+	assert.Equal(t, "成果物アーカイブ（2件）", description)
+
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"a.md", "b.json", artifacts.ManifestName}, names)
+}
@@ -1,21 +1,33 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/daemon"
+	"keruta-agent/internal/executor"
 	"keruta-agent/internal/git"
 	"keruta-agent/internal/logger"
+	"keruta-agent/internal/metrics"
+	"keruta-agent/internal/outbox"
+	"keruta-agent/internal/state"
+	"keruta-agent/internal/tracing"
+	"keruta-agent/pkg/artifacts"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -25,6 +37,16 @@ var (
 	daemonWorkspaceID  string
 	daemonSessionID    string
 	daemonPollInterval time.Duration
+	daemonPort         string
+	daemonHost         string
+
+	// logFlushTimeout はシャットダウン時に送信待ちのログを送信する最大時間です
+	logFlushTimeout = 10 * time.Second
+	// tracingShutdownTimeout はシャットダウン時に出力されていないスパンを書き出す最大時間です
+	tracingShutdownTimeout = 5 * time.Second
+
+	// controlServer はデーモンの制御用HTTPサーバーです（runDaemon実行中のみ設定されます）
+	controlServer *daemon.Daemon
 )
 
 // daemonCmd はデーモンモードでkeruta-agentを実行するコマンドです
@@ -41,8 +63,13 @@ var daemonCmd = &cobra.Command{
 - 自動エラーハンドリング
 - ヘルスチェック機能
 - グレースフルシャットダウン
-- PIDファイル管理`,
-	RunE: runDaemon,
+- PIDファイル管理
+- 制御用HTTP API（/health, /execute, /metrics, /config, /status）
+
+/executeで受け付けたタスクはポーリングで取得したタスクと同じキューで順次実行されます。`,
+	// デーモンはセッションまたはワークスペースのタスクを実行するため、タスクIDを必要としない
+	Annotations: map[string]string{taskIDAnnotation: taskIDOptional},
+	RunE:        runDaemon,
 	Example: `  # セッションのタスクを自動実行
   keruta daemon --session-id session-123
 
@@ -58,6 +85,9 @@ var daemonCmd = &cobra.Command{
   # ログファイルを指定
   keruta daemon --log-file /var/log/keruta-agent.log
 
+  # 制御用HTTP APIのホスト・ポートを指定
+  keruta daemon --host 0.0.0.0 --port 9090
+
   # Coderワークスペース内で自動実行（ワークスペース名から自動でセッションIDを検出）
   keruta daemon  # CODER_WORKSPACE_NAME環境変数またはホスト名から自動取得`,
 }
@@ -92,11 +122,71 @@ func runDaemon(_ *cobra.Command, _ []string) error {
 		daemonLogger.WithField("log_file", daemonLogFile).Info("ログファイルを設定しました")
 	}
 
+	// トレースの出力先を設定（終了時に出力されていないスパンを書き出す）
+	shutdownTracing, err := tracing.Init(context.Background(), daemon.Version())
+	if err != nil {
+		return fmt.Errorf("tracing setup failed: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			daemonLogger.WithError(err).Warn("トレースの書き出しに失敗しました")
+		}
+	}()
+
 	// APIクライアントの初期化
-	apiClient := api.NewClient()
+	rawClient := api.NewClient()
 
 	// ログのAPI送信を有効化
-	logger.SetAPIClient(apiClient)
+	// エージェント自身のログはアウトボックスを経由しないため、APIに接続できない間のログは保存されない
+	// （アウトボックスへの保存を通知するログ自体がアウトボックスに保存され続けないようにする）
+	logger.SetAPIClient(rawClient)
+	defer func() {
+		// 終了前に送信待ちのログを送信する
+		flushCtx, cancel := context.WithTimeout(context.Background(), logFlushTimeout)
+		defer cancel()
+		if err := logger.Flush(flushCtx); err != nil {
+			daemonLogger.WithError(err).Warn("送信待ちのログをすべて送信できませんでした")
+		}
+	}()
+
+	// 部分的なセッションIDから完全なUUIDを取得
+	// 部分的なIDのままではセッションのエンドポイントが404を返し、トークンを確認できないため、確認より先に行う
+	if daemonSessionID != "" {
+		daemonSessionID = resolveFullSessionID(rawClient, daemonSessionID, daemonLogger)
+	}
+
+	// トークンが拒否される場合はポーリングを開始しない
+	if err := verifyAPIToken(rawClient, daemonSessionID, daemonWorkspaceID, daemonLogger); err != nil {
+		return err
+	}
+
+	// APIに送信できなかったステータス更新とログはアウトボックスに保存して後で再送信する
+	var apiClient api.KerutaAPI = rawClient
+	box, err := outbox.Open(config.GetStateDir())
+	if err != nil {
+		daemonLogger.WithError(err).Warn("アウトボックスを開けませんでした。送信に失敗した書き込みは保存されません")
+	} else {
+		taskOutbox = outbox.NewClient(rawClient, box)
+		apiClient = taskOutbox
+		defer func() {
+			taskOutbox = nil
+		}()
+		// 前回の実行で送信できなかった書き込みを送信する
+		flushOutbox(context.Background(), daemonLogger)
+	}
+
+	// 実行中のタスクを状態ファイルに記録し、クラッシュや再起動の後に復旧できるようにする
+	store, err := state.Open(config.GetStateDir())
+	if err != nil {
+		daemonLogger.WithError(err).Warn("状態ファイルを開けませんでした。再起動時に実行中だったタスクは復旧されません")
+	} else {
+		taskState = store
+		defer func() {
+			taskState = nil
+		}()
+	}
 
 	// Gitコマンドの利用可能性を確認
 	if err := git.ValidateGitCommand(); err != nil {
@@ -105,14 +195,7 @@ func runDaemon(_ *cobra.Command, _ []string) error {
 
 	// セッションの情報を取得してGitリポジトリを初期化
 	if daemonSessionID != "" {
-		// 部分的なIDから完全なUUIDを取得
-		fullSessionID := resolveFullSessionID(apiClient, daemonSessionID, daemonLogger)
-		if fullSessionID != daemonSessionID {
-			// 完全なUUIDが取得できた場合、更新する
-			daemonSessionID = fullSessionID
-		}
-
-		if err := initializeRepositoryForSession(apiClient, daemonSessionID, daemonLogger); err != nil {
+		if err := initializeRepositoryForSession(context.Background(), apiClient, daemonSessionID, daemonLogger); err != nil {
 			daemonLogger.WithError(err).Error("リポジトリの初期化に失敗しました")
 		}
 	}
@@ -136,6 +219,27 @@ func runDaemon(_ *cobra.Command, _ []string) error {
 		cancel()
 	}()
 
+	// 制御用HTTPサーバーの開始
+	controlServer = daemon.NewDaemonWithAddress(daemonHost, daemonPort)
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		if err := controlServer.Start(ctx); err != nil {
+			daemonLogger.WithError(err).Error("制御用HTTPサーバーでエラーが発生しました")
+		}
+	}()
+	defer func() {
+		<-serverDone
+		controlServer = nil
+	}()
+
+	// 前回の実行で終了できなかったタスクを復旧する（サーバーに問い合わせできなかった場合はポーリングの前に再試行する）
+	recoveryPending := false
+	if err := recoverInFlightTask(ctx, apiClient, daemonLogger); err != nil {
+		daemonLogger.WithError(err).Warn("実行中だったタスクの状態を確認できませんでした")
+		recoveryPending = true
+	}
+
 	// メインデーモンループ
 	ticker := time.NewTicker(daemonPollInterval)
 	defer ticker.Stop()
@@ -148,15 +252,75 @@ func runDaemon(_ *cobra.Command, _ []string) error {
 			daemonLogger.Info("🛑 グレースフルシャットダウンを実行しています...")
 			return nil
 		case <-ticker.C:
+			flushOutbox(ctx, daemonLogger)
+			if recoveryPending {
+				if err := recoverInFlightTask(ctx, apiClient, daemonLogger); err != nil {
+					daemonLogger.WithError(err).Warn("実行中だったタスクの状態を確認できませんでした")
+					continue
+				}
+				recoveryPending = false
+			}
 			if err := pollAndExecuteSessionTasks(ctx, apiClient, daemonLogger); err != nil {
 				daemonLogger.WithError(err).Error("セッションタスクポーリング中にエラーが発生しました")
 			}
+		case req := <-controlServer.Tasks():
+			if err := executeRequestedTask(ctx, apiClient, req, daemonLogger); err != nil {
+				daemonLogger.WithError(err).WithField("task_id", req.TaskID).Error("HTTP経由のタスクの実行に失敗しました")
+			}
 		}
 	}
 }
 
+// authProber は認証付きエンドポイントを呼び出してトークンを確認します
+type authProber interface {
+	ProbeAuth(path string) *api.ProbeResult
+}
+
+// verifyAPIToken はポーリングを開始する前に、設定されたトークンで認証付きエンドポイントを呼び出します
+// トークンが拒否された場合はエラーを返します。APIに接続できない場合は一時的な障害の可能性があるため警告のみ出力します
+func verifyAPIToken(prober authProber, sessionID, workspaceID string, logger *logrus.Entry) error {
+	path, err := api.ProbePath("", sessionID, workspaceID)
+	if err != nil {
+		logger.WithError(err).Warn("APIトークンを確認できませんでした")
+		return nil
+	}
+
+	result := prober.ProbeAuth(path)
+	log := logger.WithFields(logrus.Fields{
+		"endpoint":    path,
+		"auth_level":  result.Level,
+		"status_code": result.StatusCode,
+		"latency":     result.Latency,
+	})
+	if result.TLSExpiry != nil {
+		log = log.WithField("tls_expiry", result.TLSExpiry.Format(time.RFC3339))
+	}
+
+	switch {
+	case result.TokenRejected():
+		log.WithError(result.Err).Error("APIトークンが拒否されました。ポーリングを開始しません")
+		return newAPIError(fmt.Errorf("API token rejected (HTTP %d): %w", result.StatusCode, result.Err))
+	case result.Level == api.AuthLevelAuthenticated:
+		log.WithError(result.Err).Warn("APIトークンは有効ですが、リソースへのアクセスを確認できませんでした。ポーリングを開始します")
+	case result.Level != api.AuthLevelAuthorized:
+		log.WithError(result.Err).Warn("APIトークンを確認できませんでした。ポーリングを開始します")
+	default:
+		log.Info("APIトークンを確認しました")
+	}
+	return nil
+}
+
 // pollAndExecuteSessionTasks はセッションからタスクをポーリングし、順次実行します
-func pollAndExecuteSessionTasks(ctx context.Context, apiClient *api.Client, logger *logrus.Entry) error {
+func pollAndExecuteSessionTasks(ctx context.Context, apiClient api.KerutaAPI, logger *logrus.Entry) (err error) {
+	ctx, span := tracing.Start(ctx, "poll",
+		attribute.String("keruta.session_id", daemonSessionID),
+		attribute.String("keruta.workspace_id", daemonWorkspaceID),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	apiClient = api.WithContext(apiClient, ctx)
+
 	logger.Debug("📡 セッションから新しいタスクをポーリングしています...")
 
 	// セッション状態の確認
@@ -241,11 +405,94 @@ func pollAndExecuteSessionTasks(ctx context.Context, apiClient *api.Client, logg
 	return nil
 }
 
+// タスクの取得元
+const (
+	taskSourcePoll = "poll"
+	taskSourceHTTP = "http"
+)
+
+// executeRequestedTask は制御用HTTP APIの/executeで受け付けたタスクを実行します
+// リクエストの環境変数はタスクのプロセスにのみ渡し、デーモン自身の環境変数は変更しません
+func executeRequestedTask(ctx context.Context, apiClient api.KerutaAPI, req *daemon.TaskRequest, parentLogger *logrus.Entry) error {
+	env := make([]string, 0, len(req.Environment))
+	for key, value := range req.Environment {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	task := &api.Task{
+		ID:          req.TaskID,
+		SessionID:   daemonSessionID,
+		Name:        "HTTP経由のタスク",
+		Description: req.Script,
+		Script:      req.Script,
+	}
+	script := &api.Script{
+		Content:  req.Script,
+		Language: req.Language,
+	}
+	return executeTaskFrom(ctx, apiClient, task, script, env, taskSourceHTTP, parentLogger)
+}
+
 // executeTask は個別のタスクを実行します
-func executeTask(ctx context.Context, apiClient *api.Client, task *api.Task, parentLogger *logrus.Entry) error {
+func executeTask(ctx context.Context, apiClient api.KerutaAPI, task *api.Task, parentLogger *logrus.Entry) error {
+	return executeTaskFrom(ctx, apiClient, task, nil, nil, taskSourcePoll, parentLogger)
+}
+
+// executeTaskFrom はタスクを実行し、実行状況を制御用HTTPサーバーに反映します
+// scriptがnilの場合はAPIからタスクのスクリプトを取得します。envはタスクのプロセスに追加で渡す環境変数です
+func executeTaskFrom(ctx context.Context, apiClient api.KerutaAPI, task *api.Task, script *api.Script, env []string, source string, parentLogger *logrus.Entry) (err error) {
 	taskLogger := parentLogger.WithField("task_id", task.ID)
+
+	// タスクのスパンを親として、Git操作・実行・API呼び出しのスパンを記録する
+	ctx, span := tracing.Start(ctx, "task",
+		attribute.String("keruta.task_id", task.ID),
+		attribute.String("keruta.session_id", task.SessionID),
+		attribute.String("keruta.task_source", source),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	apiClient = api.WithContext(apiClient, ctx)
 	taskLogger.Info("🔄 タスクを実行しています...")
 
+	controlServer.SetCurrentTask(&daemon.TaskInfo{
+		TaskID:    task.ID,
+		SessionID: task.SessionID,
+		Name:      task.Name,
+		Source:    source,
+		StartedAt: time.Now(),
+	})
+	// クラッシュした場合に再起動後に復旧できるよう、実行中のタスクを記録する
+	recordTaskStart(task, source, taskLogger)
+	// デーモンの停止で中断した場合は記録を残し、次回の起動時に復旧する
+	interrupted := false
+	defer func() {
+		if !interrupted {
+			recordTaskFinish(task.ID, taskLogger)
+		}
+	}()
+	// 失敗を通知したエラーコードとサーバー側でのキャンセルは実行結果のメトリクスに記録する
+	startedAt := time.Now()
+	var failureCode string
+	remoteCancelled := false
+	defer func() {
+		// サーバー側でキャンセルされたタスクはnilを返すが、成功としては集計しない
+		controlServer.FinishCurrentTask(err == nil && !remoteCancelled)
+		if interrupted {
+			// 復旧後にタスクが終了した時点で記録する
+			return
+		}
+		result := metrics.TaskSucceeded
+		switch {
+		case remoteCancelled:
+			result = metrics.TaskCancelled
+		case err != nil:
+			result = metrics.TaskFailed
+		}
+		metrics.ObserveTask(result, failureCode, time.Since(startedAt))
+	}()
+
 	// 環境変数にタスクIDを設定
 	oldTaskID := os.Getenv("KERUTA_TASK_ID")
 	if err := os.Setenv("KERUTA_TASK_ID", task.ID); err != nil {
@@ -262,52 +509,197 @@ func executeTask(ctx context.Context, apiClient *api.Client, task *api.Task, par
 		return fmt.Errorf("task start notification failed: %w", err)
 	}
 
-	// スクリプトの取得
-	script, err := apiClient.GetTaskScript(task.ID)
+	// スクリプトの取得（HTTP経由のタスクはリクエストのスクリプトを使用）
+	if script == nil {
+		script, err = apiClient.GetScript(task.ID)
+	}
 	if err != nil {
-		if failErr := apiClient.FailTask(task.ID, "スクリプトの取得に失敗しました", "SCRIPT_FETCH_ERROR"); failErr != nil {
-			taskLogger.WithError(failErr).Error("タスク失敗の通知に失敗しました")
-		}
+		failureCode = "SCRIPT_FETCH_ERROR"
+		reportTaskFailure(ctx, apiClient, task.ID, "スクリプトの取得に失敗しました", failureCode, taskLogger)
 		return fmt.Errorf("script retrieval failed: %w", err)
 	}
 
 	// スクリプト内容を表示
 	taskLogger.Info("📋 実行するスクリプトの内容:")
 	taskLogger.Info("=" + strings.Repeat("=", 50))
-	for i, line := range strings.Split(script, "\n") {
+	for i, line := range strings.Split(script.Content, "\n") {
 		taskLogger.Infof("%3d | %s", i+1, line)
 	}
 	taskLogger.Info("=" + strings.Repeat("=", 50))
 
-	reader, writer := io.Pipe()
-	err = writeStdIn(writer, task, apiClient)
+	// タスク専用ブランチの作成・チェックアウト
+	branchName, err := setupTaskBranch(ctx, apiClient, task.SessionID, task.ID, taskLogger)
 	if err != nil {
+		failureCode = "BRANCH_SETUP_ERROR"
+		if errors.Is(err, git.ErrDirtyWorkingTree) || errors.Is(err, git.ErrCheckoutConflict) {
+			failureCode = "BRANCH_CONFLICT"
+		}
+		reportTaskFailure(ctx, apiClient, task.ID, fmt.Sprintf("タスク用ブランチの準備に失敗しました: %v", err), failureCode, taskLogger)
+		return fmt.Errorf("task branch setup failed: %w", err)
+	}
+	if branchName != "" {
+		taskLogger = taskLogger.WithField("branch", branchName)
+		recordTaskBranch(task.ID, branchName, taskLogger)
+		if err := apiClient.UpdateTaskStatus(task.ID, api.TaskStatusProcessing, fmt.Sprintf("ブランチ %s でタスクを実行しています", branchName), 0, ""); err != nil {
+			taskLogger.WithError(err).Warn("ブランチ名の報告に失敗しました")
+		}
+	}
+
+	// 作業ディレクトリの決定（セッションのリポジトリ + TemplatePath）
+	workDir, err := resolveTaskWorkingDir(apiClient, task.SessionID, taskLogger)
+	if err != nil {
+		failureCode = "WORKING_DIR_ERROR"
+		reportTaskFailure(ctx, apiClient, task.ID, fmt.Sprintf("作業ディレクトリの決定に失敗しました: %v", err), failureCode, taskLogger)
+		return fmt.Errorf("working directory resolution failed: %w", err)
+	}
+
+	// スクリプトの言語に対応するランナーを選択
+	runner, err := executor.Lookup(script.Language)
+	if err != nil {
+		failureCode = "UNSUPPORTED_LANGUAGE"
+		reportTaskFailure(ctx, apiClient, task.ID, err.Error(), failureCode, taskLogger)
+		return fmt.Errorf("executor lookup failed: %w", err)
+	}
+
+	// Claudeに渡すタスクの内容
+	var prompt bytes.Buffer
+	if err := writeStdIn(&prompt, task, apiClient); err != nil {
 		return err
 	}
-	// スクリプトの実行 - 常にclaudeコマンドを使用
-	if err := executeClaudeTask(ctx, apiClient, task.ID,
-		reader,
-		taskLogger); err != nil {
-		if failErr := apiClient.FailTask(task.ID, fmt.Sprintf("Claude タスクの実行に失敗しました: %v", err), "CLAUDE_EXECUTION_ERROR"); failErr != nil {
-			taskLogger.WithError(failErr).Error("タスク失敗の通知に失敗しました")
+
+	// サーバー側でのキャンセルを検知したら実行を停止できるようにする
+	cancelCtx, cancelTask := context.WithCancelCause(ctx)
+	defer cancelTask(nil)
+
+	// タスクの最大実行時間を設定（Parametersのtimeoutで上書き可能）
+	timeout := taskTimeout(task, taskLogger)
+	execCtx := cancelCtx
+	if timeout > 0 {
+		var cancelExec context.CancelFunc
+		execCtx, cancelExec = context.WithTimeout(cancelCtx, timeout)
+		defer cancelExec()
+	}
+
+	watchCtx, stopWatch := context.WithCancel(cancelCtx)
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		watchRemoteCancellation(watchCtx, apiClient, task.ID, cancelTask, taskLogger)
+	}()
+
+	taskLogger.WithFields(logrus.Fields{
+		"executor": runner.Name(),
+		"timeout":  timeout,
+	}).Info("ランナーを選択しました")
+	execCtx, execSpan := tracing.Start(execCtx, "task.execute",
+		attribute.String("keruta.executor", runner.Name()),
+		attribute.String("keruta.timeout", timeout.String()),
+	)
+	// タイムアウトやキャンセルでプロセスを停止した後の出力も送信する
+	output := api.WithContext(apiClient, context.WithoutCancel(execCtx))
+	execErr := runner.Execute(execCtx, &executor.Request{
+		TaskID:          task.ID,
+		WorkDir:         workDir,
+		Script:          *script,
+		Prompt:          &prompt,
+		Env:             env,
+		Output:          output,
+		Logger:          taskLogger,
+		KillGracePeriod: config.GetKillGracePeriod(),
+		// エージェントがクラッシュした場合に、再起動後に残ったプロセスを停止できるようにする
+		OnStart: func(pgid int) {
+			recordTaskProcessGroup(task.ID, pgid, taskLogger)
+		},
+	})
+	tracing.End(execSpan, execErr)
+	stopWatch()
+	<-watchDone
+
+	// サーバー側でキャンセルされた場合は、プッシュも最終ステータスの送信も行わない
+	cancelled := context.Cause(cancelCtx)
+	if execErr == nil {
+		// 実行完了と同時にキャンセルされた場合に備えて、完了通知の前にもう一度確認する
+		if err := checkRemoteCancellation(apiClient, task.ID, taskLogger); err != nil {
+			cancelled = err
 		}
-		return fmt.Errorf("claude task execution failed: %w", err)
+	}
+	var remoteErr *remoteCancellationError
+	if errors.As(cancelled, &remoteErr) {
+		// 成果物はアップロードしないが、次のタスクに持ち越さないようローテーションする
+		remoteCancelled = true
+		rotateTaskArtifacts(artifacts.NewManager(), task.ID, taskLogger)
+		taskLogger.WithField("remote_status", remoteErr.status).Info("🚫 タスクはサーバー側でキャンセルされたため、プッシュと完了通知をスキップしました")
+		return nil
+	}
+
+	// デーモンの停止で中断した場合は失敗を通知せず、次回の起動時に復旧の方法に従って再開・再キューする
+	if execErr != nil && ctx.Err() != nil && !errors.Is(execErr, executor.ErrTimeout) {
+		interrupted = true
+		taskLogger.WithError(execErr).Warn("⏸️ デーモンの停止によってタスクを中断しました。次回の起動時に復旧します")
+		return fmt.Errorf("%s task interrupted by shutdown: %w", runner.Name(), execErr)
+	}
+
+	// 成果物の収集とアップロード（失敗したタスクの成果物も調査用にアップロードする）
+	if err := uploadTaskArtifacts(apiClient, task, taskLogger); err != nil {
+		taskLogger.WithError(err).Warn("成果物のアップロードに失敗しました")
+	}
+
+	if execErr != nil {
+		message := "スクリプトの実行に失敗しました"
+		failureCode = "SCRIPT_EXECUTION_ERROR"
+		if runner.Name() == "claude" {
+			message, failureCode = "Claude タスクの実行に失敗しました", "CLAUDE_EXECUTION_ERROR"
+		}
+		if errors.Is(execErr, executor.ErrTimeout) {
+			message, failureCode = fmt.Sprintf("タスクが最大実行時間（%s）を超えたため停止しました", timeout), "TIMEOUT"
+		}
+		reportTaskFailure(ctx, apiClient, task.ID, fmt.Sprintf("%s: %v", message, execErr), failureCode, taskLogger)
+		return fmt.Errorf("%s task execution failed: %w", runner.Name(), execErr)
 	}
 
 	// タスク完了後にGit変更をプッシュ
-	if err := pushTaskChanges(apiClient, task.SessionID, task.ID, taskLogger); err != nil {
+	if err := pushTaskChanges(ctx, apiClient, task.SessionID, task.ID, taskLogger); err != nil {
 		taskLogger.WithError(err).Warn("変更のプッシュに失敗しました（タスクは完了扱いとします）")
 	}
 
 	// タスク成功の通知
-	if err := apiClient.SuccessTask(task.ID, "タスクが正常に完了しました"); err != nil {
+	if err := reportTaskSuccess(ctx, apiClient, task.ID, "タスクが正常に完了しました"); err != nil {
 		return fmt.Errorf("task success notification failed: %w", err)
 	}
 
 	taskLogger.Info("✅ タスクが正常に完了しました")
 	return nil
 }
-func writeStdIn(writer *io.PipeWriter, task *api.Task, apiClient *api.Client) error {
+
+// taskTimeout はタスクの最大実行時間を返します
+// タスクのParametersにtimeout（"30m"のような時間表記または秒数）がある場合は設定値より優先します
+func taskTimeout(task *api.Task, logger *logrus.Entry) time.Duration {
+	timeout := config.GetTaskTimeout()
+
+	value, ok := task.Parameters["timeout"]
+	if !ok || value == nil {
+		return timeout
+	}
+
+	var override time.Duration
+	var err error
+	switch v := value.(type) {
+	case float64:
+		override = time.Duration(v * float64(time.Second))
+	case string:
+		override, err = config.ParseDurationOrSeconds(v)
+	default:
+		err = fmt.Errorf("unsupported type %T", value)
+	}
+	if err != nil || override < 0 {
+		logger.WithField("timeout", value).Warn("タスクのtimeoutパラメータが不正なため、設定値を使用します")
+		return timeout
+	}
+	return override
+}
+
+// writeStdIn はClaudeに渡すタスクの内容（親タスクの情報を含む）を書き込みます
+func writeStdIn(writer io.Writer, task *api.Task, apiClient api.KerutaAPI) error {
 	content := "# " + task.Name + "\n" +
 		"## description\n" +
 		"" + task.Description
@@ -379,6 +771,8 @@ func init() {
 	daemonCmd.Flags().StringVar(&daemonWorkspaceID, "workspace-id", "", "ワークスペースID（環境変数KERUTA_WORKSPACE_IDから自動取得）")
 	daemonCmd.Flags().StringVar(&daemonPidFile, "pid-file", "", "PIDファイルのパス")
 	daemonCmd.Flags().StringVar(&daemonLogFile, "log-file", "", "ログファイルのパス")
+	daemonCmd.Flags().StringVar(&daemonPort, "port", config.GetDaemonPort(), "制御用HTTP APIのポート（環境変数KERUTA_DAEMON_PORT）")
+	daemonCmd.Flags().StringVar(&daemonHost, "host", config.GetDaemonHost(), "制御用HTTP APIのホスト（環境変数KERUTA_DAEMON_HOST）")
 
 	// 環境変数からのデフォルト値設定
 	if sessionID := os.Getenv("KERUTA_SESSION_ID"); sessionID != "" {
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/daemon"
+	"keruta-agent/internal/state"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskTimeout(t *testing.T) {
+	config.GlobalConfig = &config.Config{
+		Task: config.TaskConfig{Timeout: time.Hour},
+	}
+	defer func() {
+		config.GlobalConfig = nil
+	}()
+	logger := logrus.NewEntry(logrus.New())
+
+	// パラメータがない場合は設定値
+	assert.Equal(t, time.Hour, taskTimeout(&api.Task{}, logger))
+
+	// 時間表記・秒数（文字列・数値）で上書き
+	assert.Equal(t, 30*time.Minute, taskTimeout(&api.Task{Parameters: map[string]interface{}{"timeout": "30m"}}, logger))
+	assert.Equal(t, 90*time.Second, taskTimeout(&api.Task{Parameters: map[string]interface{}{"timeout": "90"}}, logger))
+	assert.Equal(t, 45*time.Second, taskTimeout(&api.Task{Parameters: map[string]interface{}{"timeout": float64(45)}}, logger))
+
+	// 0は無制限
+	assert.Equal(t, time.Duration(0), taskTimeout(&api.Task{Parameters: map[string]interface{}{"timeout": "0"}}, logger))
+
+	// 不正な値は設定値
+	assert.Equal(t, time.Hour, taskTimeout(&api.Task{Parameters: map[string]interface{}{"timeout": "soon"}}, logger))
+	assert.Equal(t, time.Hour, taskTimeout(&api.Task{Parameters: map[string]interface{}{"timeout": true}}, logger))
+}
+
+func TestExecuteTaskRemoteCancellation(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+
+	var (
+		mu       sync.Mutex
+		statuses []api.TaskStatus
+	)
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/status"):
+			var req api.TaskUpdateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mu.Lock()
+			statuses = append(statuses, req.Status)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/tasks/cancel-task":
+			// UIからキャンセルされたタスク
+			require.NoError(t, json.NewEncoder(w).Encode(api.Task{ID: "cancel-task", Status: api.TaskStatusCancelled}))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	t.Setenv("HOME", t.TempDir())
+
+	oldInterval := taskWatchInterval
+	taskWatchInterval = 20 * time.Millisecond
+	defer func() {
+		taskWatchInterval = oldInterval
+	}()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	start := time.Now()
+	err := executeTaskFrom(context.Background(), api.NewClient(), &api.Task{ID: "cancel-task", Name: "cancel"},
+		&api.Script{Content: "sleep 30", Language: "sh"}, nil, taskSourceHTTP, logrus.NewEntry(logger))
+
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// 開始通知のみで、完了・失敗のステータスは送信しない
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusProcessing}, statuses)
+}
+
+func TestExecuteTaskShutdownKeepsRecord(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu       sync.Mutex
+		statuses []api.TaskStatus
+	)
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		switch {
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/status"):
+			var req api.TaskUpdateRequest
+			require.NoError(t, json.Unmarshal(body, &req))
+			mu.Lock()
+			statuses = append(statuses, req.Status)
+			mu.Unlock()
+		case r.Method == http.MethodPost && strings.Contains(string(body), "started"):
+			// タスクの実行中にデーモンを停止する
+			cancel()
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/tasks/shutdown-task":
+			require.NoError(t, json.NewEncoder(w).Encode(api.Task{ID: "shutdown-task", Status: api.TaskStatusProcessing}))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := state.Open(t.TempDir())
+	require.NoError(t, err)
+	taskState = store
+	defer func() {
+		taskState = nil
+	}()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	start := time.Now()
+	err = executeTaskFrom(ctx, api.NewClient(), &api.Task{ID: "shutdown-task", Name: "shutdown"},
+		&api.Script{Content: "echo started\nsleep 30", Language: "sh"}, nil, taskSourcePoll, logrus.NewEntry(logger))
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// 失敗を通知せず、次回の起動時に復旧できるよう記録を残す
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusProcessing}, statuses)
+	require.NotNil(t, store.Current())
+	assert.Equal(t, "shutdown-task", store.Current().TaskID)
+}
+
+func TestExecuteRequestedTaskEnvironment(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	t.Setenv("HOME", t.TempDir())
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// リクエストの環境変数はタスクのプロセスにのみ渡す
+	out := filepath.Join(t.TempDir(), "out")
+	err := executeRequestedTask(context.Background(), api.NewClient(), &daemon.TaskRequest{
+		TaskID:      "env-task",
+		Script:      `printf %s "$KERUTA_PARAM_GREETING" > ` + out,
+		Language:    "sh",
+		Environment: map[string]string{"KERUTA_PARAM_GREETING": "hello"},
+	}, logrus.NewEntry(logger))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, set := os.LookupEnv("KERUTA_PARAM_GREETING")
+	assert.False(t, set)
+}
+
+func TestReportTaskSuccessRetriesUntilDelivered(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		// APIの再起動中を想定し、最初の2回は失敗させる
+		if calls <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	oldPolicy := finalStatusRetryPolicy
+	finalStatusRetryPolicy = api.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	defer func() {
+		finalStatusRetryPolicy = oldPolicy
+	}()
+
+	// デーモンの停止中でも最終ステータスは送信する
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// デーモンのコンテキストを束縛したクライアントでも、キャンセルを引き継がずに送信する
+	err := reportTaskSuccess(ctx, api.WithContext(api.NewClient(), ctx), "durable-task", "done")
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, calls)
+}
+
+// stubProber は決まった結果を返す認証確認用のクライアントです
+type stubProber struct {
+	result *api.ProbeResult
+	path   string
+}
+
+func (p *stubProber) ProbeAuth(path string) *api.ProbeResult {
+	p.path = path
+	return p.result
+}
+
+func TestVerifyAPIToken(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+
+	tests := []struct {
+		name    string
+		result  *api.ProbeResult
+		wantErr bool
+	}{
+		{"authorized", &api.ProbeResult{Level: api.AuthLevelAuthorized, StatusCode: http.StatusOK}, false},
+		{"unreachable", &api.ProbeResult{Level: api.AuthLevelUnreachable, Err: assert.AnError}, false},
+		{"server error", &api.ProbeResult{Level: api.AuthLevelReachable, StatusCode: http.StatusBadGateway, Err: assert.AnError}, false},
+		{"unauthorized", &api.ProbeResult{Level: api.AuthLevelReachable, StatusCode: http.StatusUnauthorized, Err: assert.AnError}, true},
+		{"forbidden", &api.ProbeResult{Level: api.AuthLevelAuthenticated, StatusCode: http.StatusForbidden, Err: assert.AnError}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober := &stubProber{result: tt.result}
+			err := verifyAPIToken(prober, "session-1", "workspace-1", logger)
+			assert.Equal(t, "/api/v1/sessions/session-1", prober.path)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, ExitCodeAPIError, ExitCode(err))
+		})
+	}
+
+	// 確認するエンドポイントがない場合はポーリングを止めない
+	prober := &stubProber{}
+	assert.NoError(t, verifyAPIToken(prober, "", "", logger))
+	assert.Empty(t, prober.path)
+}
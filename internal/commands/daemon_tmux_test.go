@@ -163,11 +163,19 @@ func TestExecuteTmuxCommandInSessionLogic(t *testing.T) {
 // モック用の構造体とメソッド
 type MockAPIClient struct {
 	sessions map[string]*api.Session
+	tasks    map[string]*api.Task
+	scripts  map[string]*api.Script
+	statuses []api.TaskStatus
+	logs     []api.LogRequest
 }
 
+var _ api.KerutaAPI = (*MockAPIClient)(nil)
+
 func NewMockAPIClient() *MockAPIClient {
 	return &MockAPIClient{
 		sessions: make(map[string]*api.Session),
+		tasks:    make(map[string]*api.Task),
+		scripts:  make(map[string]*api.Script),
 	}
 }
 
@@ -178,11 +186,108 @@ func (m *MockAPIClient) GetSession(sessionID string) (*api.Session, error) {
 	return nil, fmt.Errorf("session not found")
 }
 
+func (m *MockAPIClient) SearchSessionByPartialID(partialID string) (*api.Session, error) {
+	for id, session := range m.sessions {
+		if len(id) >= len(partialID) && id[:len(partialID)] == partialID {
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found")
+}
+
+func (m *MockAPIClient) SearchSessionByName(name string) (*api.Session, error) {
+	for _, session := range m.sessions {
+		if session.Name == name {
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found")
+}
+
 func (m *MockAPIClient) SendLog(taskID, level, message string) error {
 	// モック実装 - ログを送信したと仮定
+	m.logs = append(m.logs, api.LogRequest{Level: level, Message: message})
+	return nil
+}
+
+func (m *MockAPIClient) SendLogBatch(taskID string, logs []api.LogRequest) error {
+	m.logs = append(m.logs, logs...)
+	return nil
+}
+
+func (m *MockAPIClient) UpdateTaskStatus(taskID string, status api.TaskStatus, message string, progress int, errorCode string) error {
+	m.statuses = append(m.statuses, status)
+	return nil
+}
+
+func (m *MockAPIClient) StartTask(taskID string) error {
+	return m.UpdateTaskStatus(taskID, api.TaskStatusProcessing, "", 0, "")
+}
+
+func (m *MockAPIClient) SuccessTask(taskID string, message string) error {
+	return m.UpdateTaskStatus(taskID, api.TaskStatusCompleted, message, 100, "")
+}
+
+func (m *MockAPIClient) FailTask(taskID string, message string, errorCode string) error {
+	return m.UpdateTaskStatus(taskID, api.TaskStatusFailed, message, 0, errorCode)
+}
+
+func (m *MockAPIClient) CreateAutoFixTask(taskID string, errorMessage string, errorCode string) error {
 	return nil
 }
 
+func (m *MockAPIClient) UploadArtifact(taskID string, filePath string, description string) error {
+	return nil
+}
+
+func (m *MockAPIClient) ListArtifacts(taskID string) ([]api.Artifact, error) {
+	return nil, nil
+}
+
+func (m *MockAPIClient) DeleteArtifact(taskID string, artifactID string) error {
+	return nil
+}
+
+func (m *MockAPIClient) GetTask(taskID string) (*api.Task, error) {
+	if task, exists := m.tasks[taskID]; exists {
+		return task, nil
+	}
+	return nil, fmt.Errorf("task not found")
+}
+
+func (m *MockAPIClient) GetScript(taskID string) (*api.Script, error) {
+	if script, exists := m.scripts[taskID]; exists {
+		return script, nil
+	}
+	return nil, fmt.Errorf("script not found")
+}
+
+func (m *MockAPIClient) GetTaskScript(taskID string) (string, error) {
+	script, err := m.GetScript(taskID)
+	if err != nil {
+		return "", err
+	}
+	return script.Content, nil
+}
+
+func (m *MockAPIClient) WaitForInput(taskID string, prompt string) (string, error) {
+	return "", fmt.Errorf("input not available")
+}
+
+func (m *MockAPIClient) GetPendingTasksForSession(sessionID string) ([]*api.Task, error) {
+	var tasks []*api.Task
+	for _, task := range m.tasks {
+		if task.SessionID == sessionID && task.Status == "PENDING" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+func (m *MockAPIClient) GetPendingTasksForWorkspace(workspaceID string) ([]*api.Task, error) {
+	return nil, nil
+}
+
 func TestTmuxSessionLifecycle(t *testing.T) {
 	// テスト用のセットアップ
 	originalSessionID := daemonSessionID
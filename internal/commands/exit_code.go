@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 終了コード
+// シェルスクリプトから判定できるよう、失敗の種類ごとに固定の値を返します
+const (
+	// ExitCodeSuccess は正常終了を表します
+	ExitCodeSuccess = 0
+	// ExitCodeError は分類されないエラーを表します
+	ExitCodeError = 1
+	// ExitCodeUsageError は引数・フラグ・環境変数の指定誤りを表します
+	ExitCodeUsageError = 2
+	// ExitCodeAPIError はkeruta APIの呼び出し失敗を表します
+	ExitCodeAPIError = 3
+	// ExitCodeUnhealthy はヘルスチェックで異常が見つかったことを表します
+	ExitCodeUnhealthy = 4
+)
+
+// ExitError は終了コード付きのエラーです
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error はエラーメッセージを返します
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap は元のエラーを返します
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// newUsageError は引数の指定誤りを表すエラーを作成します
+func newUsageError(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitCodeUsageError, Err: fmt.Errorf(format, args...)}
+}
+
+// newAPIError はAPI呼び出しの失敗を表すエラーを作成します
+func newAPIError(err error) error {
+	return &ExitError{Code: ExitCodeAPIError, Err: err}
+}
+
+// ExitCode はエラーに対応するプロセスの終了コードを返します
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitCodeError
+}
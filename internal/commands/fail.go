@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	failErrorCode string
+	failAutoFix   bool
+)
+
+// failCmd はタスクの失敗を報告するコマンドです
+var failCmd = &cobra.Command{
+	Use:   "fail",
+	Short: "タスクの失敗を報告",
+	Long: `タスクの失敗を報告し、ステータスをFAILEDに更新します。
+--auto-fixが指定されていない場合は設定error_handling.auto_fixに従って自動修正タスクを作成します。
+
+終了コード:
+  0  正常終了（--no-fail-on-api-error指定時はAPIエラーでも0）
+  1  予期しないエラー
+  2  引数・タスクIDの指定誤り
+  3  keruta APIの呼び出しに失敗`,
+	Args: lifecycleNoArgs,
+	RunE: runFail,
+	Example: `  # エラー発生時
+  keruta fail --message "データベース接続に失敗しました" --error-code DB_CONNECTION_ERROR
+
+  # シェルのエラートラップから呼び出す
+  trap 'keruta fail --message "予期しないエラーが発生しました: $?" --no-fail-on-api-error' ERR`,
+}
+
+func runFail(cmd *cobra.Command, _ []string) error {
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	message := messageOrDefault("タスクが失敗しました")
+	apiClient := api.NewClient()
+	if err := apiClient.FailTask(taskID, message, failErrorCode); err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("task failure notification failed: %w", err))
+	}
+
+	failLogger := logger.WithTaskID().WithField("error_code", failErrorCode)
+	failLogger.Info("タスクの失敗を報告しました")
+
+	// 自動修正タスクの作成（フラグ未指定の場合は設定に従う）
+	autoFix := config.GlobalConfig.ErrorHandling.AutoFix
+	if cmd.Flags().Changed("auto-fix") {
+		autoFix = failAutoFix
+	}
+	if autoFix {
+		if err := apiClient.CreateAutoFixTask(taskID, message, failErrorCode); err != nil {
+			failLogger.WithError(err).Warn("自動修正タスクの作成に失敗しました")
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	addLifecycleFlags(failCmd)
+	failCmd.Flags().StringVar(&failErrorCode, "error-code", "TASK_FAILED", "エラーコード")
+	failCmd.Flags().BoolVar(&failAutoFix, "auto-fix", false, "自動修正タスクを作成するかどうか（未指定時は設定error_handling.auto_fixに従う）")
+}
@@ -1,18 +1,27 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"keruta-agent/internal/api"
 	"keruta-agent/internal/git"
+	"keruta-agent/internal/tracing"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // initializeRepositoryForSession はセッションのGitリポジトリを初期化します
-func initializeRepositoryForSession(apiClient *api.Client, sessionID string, logger *logrus.Entry) error {
+func initializeRepositoryForSession(ctx context.Context, apiClient api.KerutaAPI, sessionID string, logger *logrus.Entry) (err error) {
+	ctx, span := tracing.Start(ctx, "git.clone_or_pull", attribute.String("keruta.session_id", sessionID))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	apiClient = api.WithContext(apiClient, ctx)
+
 	logger.Info("🔧 セッションのリポジトリ情報を取得しています...")
 
 	// セッション情報を取得
@@ -77,35 +86,110 @@ func initializeRepositoryForSession(apiClient *api.Client, sessionID string, log
 	return nil
 }
 
-// setupTaskBranch はタスク専用のブランチを作成・チェックアウトします
-func setupTaskBranch(apiClient *api.Client, sessionID, taskID string, logger *logrus.Entry) error {
+// resolveTaskWorkingDir はタスクを実行する作業ディレクトリを決定します
+// セッションにリポジトリがある場合はクローン先のディレクトリにTemplatePathを加えたパスを使用し、
+// ディレクトリが存在しない場合はエラーを返します。リポジトリがない場合は従来通り~/kerutaを使用します
+func resolveTaskWorkingDir(apiClient api.KerutaAPI, sessionID string, logger *logrus.Entry) (string, error) {
+	if sessionID == "" {
+		return defaultTaskWorkingDir(logger)
+	}
+
+	session, err := apiClient.GetSession(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("セッション情報の取得に失敗: %w", err)
+	}
+
+	if session.RepositoryURL == "" {
+		return defaultTaskWorkingDir(logger)
+	}
+
+	repoDir := git.DetermineWorkingDirectory(sessionID, session.RepositoryURL)
+
+	templatePath := ""
+	if session.TemplateConfig != nil {
+		templatePath = session.TemplateConfig.TemplatePath
+	}
+
+	workDir, err := git.ResolveTemplateDirectory(repoDir, templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(workDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("作業ディレクトリが存在しません（リポジトリの初期化に失敗した可能性があります）: %s", workDir)
+		}
+		return "", fmt.Errorf("作業ディレクトリの確認に失敗: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("作業ディレクトリがディレクトリではありません: %s", workDir)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"repository_dir": repoDir,
+		"template_path":  templatePath,
+		"working_dir":    workDir,
+	}).Debug("タスクの作業ディレクトリを決定しました")
+
+	return workDir, nil
+}
+
+// defaultTaskWorkingDir はリポジトリが設定されていない場合の作業ディレクトリ（~/keruta）を返します
+func defaultTaskWorkingDir(logger *logrus.Entry) (string, error) {
+	kerutaDir := os.ExpandEnv("$HOME/keruta")
+	if err := ensureDirectory(kerutaDir); err != nil {
+		return "", fmt.Errorf("~/kerutaディレクトリの作成に失敗: %w", err)
+	}
+	logger.WithField("working_dir", kerutaDir).Debug("リポジトリが設定されていないため、~/kerutaで実行します")
+	return kerutaDir, nil
+}
+
+// ensureDirectory はディレクトリの存在を確認し、存在しない場合は作成します
+func ensureDirectory(dirPath string) error {
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		return os.MkdirAll(dirPath, 0755)
+	}
+	return nil
+}
+
+// setupTaskBranch はタスク専用のブランチを作成・チェックアウトし、そのブランチ名を返します
+// リポジトリが設定されていない場合は空文字を返します
+func setupTaskBranch(ctx context.Context, apiClient api.KerutaAPI, sessionID, taskID string, logger *logrus.Entry) (branchName string, err error) {
+	ctx, span := tracing.Start(ctx, "git.setup_branch", attribute.String("keruta.task_id", taskID))
+	defer func() {
+		span.SetAttributes(attribute.String("keruta.branch", branchName))
+		tracing.End(span, err)
+	}()
+	apiClient = api.WithContext(apiClient, ctx)
+
 	// 作業ディレクトリが設定されているかチェック
 	workDir := os.Getenv("KERUTA_WORKING_DIR")
 	if workDir == "" {
 		logger.Debug("作業ディレクトリが設定されていないため、ブランチ作成をスキップします")
-		return nil
+		return "", nil
 	}
 
 	// ディレクトリがGitリポジトリかチェック
 	gitDir := filepath.Join(workDir, ".git")
 	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
 		logger.Debug("作業ディレクトリがGitリポジトリではないため、ブランチ作成をスキップします")
-		return nil
+		return "", nil
 	}
 
 	// セッション情報を取得してリポジトリ設定を確認
 	session, err := apiClient.GetSession(sessionID)
 	if err != nil {
-		return fmt.Errorf("セッション情報の取得に失敗: %w", err)
+		return "", fmt.Errorf("セッション情報の取得に失敗: %w", err)
 	}
 
 	if session.RepositoryURL == "" {
 		logger.Debug("セッションにリポジトリURLが設定されていないため、ブランチ作成をスキップします")
-		return nil
+		return "", nil
 	}
 
 	// タスク専用のブランチ名を生成
-	branchName := git.GenerateBranchName(sessionID, taskID)
+	branchName = git.GenerateBranchName(sessionID, taskID)
 
 	logger.WithFields(logrus.Fields{
 		"session_id":  sessionID,
@@ -124,11 +208,20 @@ func setupTaskBranch(apiClient *api.Client, sessionID, taskID string, logger *lo
 	)
 
 	// 新しいブランチを作成・チェックアウト
-	return repo.CreateAndCheckoutBranch()
+	if err := repo.CreateAndCheckoutBranch(); err != nil {
+		return "", err
+	}
+	return branchName, nil
 }
 
 // pushTaskChanges はタスク完了後に変更をコミット・プッシュします
-func pushTaskChanges(apiClient *api.Client, sessionID, taskID string, logger *logrus.Entry) error {
+func pushTaskChanges(ctx context.Context, apiClient api.KerutaAPI, sessionID, taskID string, logger *logrus.Entry) (err error) {
+	ctx, span := tracing.Start(ctx, "git.commit_push", attribute.String("keruta.task_id", taskID))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	apiClient = api.WithContext(apiClient, ctx)
+
 	// 作業ディレクトリが設定されているかチェック
 	workDir := os.Getenv("KERUTA_WORKING_DIR")
 	if workDir == "" {
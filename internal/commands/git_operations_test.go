@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keruta-agent/internal/api"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSessionMock(session api.Session) api.KerutaAPI {
+	apiClient := NewMockAPIClient()
+	apiClient.sessions[session.ID] = &session
+	return apiClient
+}
+
+func TestResolveTaskWorkingDirWithTemplatePath(t *testing.T) {
+	repoDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "templates", "web"), 0755))
+	t.Setenv("KERUTA_WORKING_DIR", repoDir)
+
+	apiClient := newSessionMock(api.Session{
+		ID:             "session-123",
+		RepositoryURL:  "https://github.com/example/repo.git",
+		TemplateConfig: &api.SessionTemplateConfig{TemplatePath: "templates/web"},
+	})
+
+	workDir, err := resolveTaskWorkingDir(apiClient, "session-123", logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repoDir, "templates", "web"), workDir)
+}
+
+func TestResolveTaskWorkingDirMissing(t *testing.T) {
+	repoDir := t.TempDir()
+	t.Setenv("KERUTA_WORKING_DIR", repoDir)
+
+	apiClient := newSessionMock(api.Session{
+		ID:             "session-123",
+		RepositoryURL:  "https://github.com/example/repo.git",
+		TemplateConfig: &api.SessionTemplateConfig{TemplatePath: "missing"},
+	})
+
+	_, err := resolveTaskWorkingDir(apiClient, "session-123", logrus.NewEntry(logrus.New()))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "作業ディレクトリが存在しません")
+}
+
+func TestResolveTaskWorkingDirWithoutRepository(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	apiClient := newSessionMock(api.Session{ID: "session-123"})
+
+	workDir, err := resolveTaskWorkingDir(apiClient, "session-123", logrus.NewEntry(logrus.New()))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(os.Getenv("HOME"), "keruta"), workDir)
+	assert.DirExists(t, workDir)
+}
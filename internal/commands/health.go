@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"keruta-agent/pkg/health"
+
+	"github.com/spf13/cobra"
+)
+
+const megabyte = 1024 * 1024
+
+var (
+	// health コマンドのフラグ
+	healthCheckAPI    bool
+	healthCheckAuth   bool
+	healthCheckDisk   bool
+	healthCheckMemory bool
+	healthCheckTools  bool
+	healthJSON        bool
+	healthMinFreeDisk uint64
+	healthMinMemory   uint64
+	// healthMinFreeDiskPercent と healthMinMemoryPercent は空き容量の割合の閾値（%）です
+	healthMinFreeDiskPercent float64
+	healthMinMemoryPercent   float64
+)
+
+// healthCmd はエージェントの実行環境をチェックするコマンドです
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "ヘルスチェックを実行",
+	Long: `keruta APIとの接続、APIトークンの有効性、ディスクの空き容量、メモリ、依存コマンド（claude, git, tmux）をチェックします。
+チェックを指定しない場合はすべてのチェックを実行します。
+
+終了コード:
+  0  すべてのチェックが正常
+  1  予期しないエラー
+  4  異常なチェックがある`,
+	// ヘルスチェックはタスクの外（Podのプローブなど）からも実行できるようにする
+	Annotations: map[string]string{taskIDAnnotation: taskIDOptional},
+	Args:        lifecycleNoArgs,
+	RunE:        runHealth,
+	Example: `  keruta health
+  keruta health --check-disk --check-memory
+  keruta health --check-auth
+  keruta health --json`,
+}
+
+func runHealth(cmd *cobra.Command, _ []string) error {
+	checker := health.NewChecker()
+	thresholds := health.DefaultThresholds()
+	thresholds.MinFreeDisk = healthMinFreeDisk * megabyte
+	thresholds.MinAvailableMemory = healthMinMemory * megabyte
+	thresholds.MinFreeDiskPercent = healthMinFreeDiskPercent
+	thresholds.MinAvailableMemoryPercent = healthMinMemoryPercent
+	checker.SetThresholds(thresholds)
+
+	status := runHealthChecks(checker)
+	if err := writeHealthStatus(cmd.OutOrStdout(), status, healthJSON); err != nil {
+		return err
+	}
+	if !status.Overall {
+		cmd.SilenceUsage = true
+		return &ExitError{Code: ExitCodeUnhealthy, Err: fmt.Errorf("health check failed: %s", failedHealthChecks(status))}
+	}
+	return nil
+}
+
+// runHealthChecks はフラグで指定されたチェックを実行します（指定がない場合はすべて）
+func runHealthChecks(checker *health.Checker) *health.HealthStatus {
+	selected := map[string]bool{
+		"api":    healthCheckAPI,
+		"auth":   healthCheckAuth,
+		"disk":   healthCheckDisk,
+		"memory": healthCheckMemory,
+		"tools":  healthCheckTools,
+	}
+	var checks []string
+	for name, enabled := range selected {
+		if enabled {
+			checks = append(checks, name)
+		}
+	}
+	if len(checks) == 0 {
+		return checker.CheckAll()
+	}
+
+	status := &health.HealthStatus{
+		Overall:   true,
+		Timestamp: time.Now(),
+		Checks:    make(map[string]health.CheckResult, len(checks)),
+	}
+	for _, name := range checks {
+		result := checker.CheckSpecific(name)
+		status.Checks[name] = result
+		if !result.Status {
+			status.Overall = false
+		}
+	}
+	return status
+}
+
+// writeHealthStatus はチェック結果をJSONまたはテキストで出力します
+func writeHealthStatus(w io.Writer, status *health.HealthStatus, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	}
+
+	for _, name := range sortedHealthChecks(status) {
+		result := status.Checks[name]
+		mark := "OK"
+		if !result.Status {
+			mark = "NG"
+		}
+		fmt.Fprintf(w, "[%s] %s: %s\n", mark, name, result.Message)
+		if result.Error != "" {
+			fmt.Fprintf(w, "     %s\n", result.Error)
+		}
+	}
+	if status.Overall {
+		fmt.Fprintln(w, "正常")
+	} else {
+		fmt.Fprintln(w, "異常があります")
+	}
+	return nil
+}
+
+// failedHealthChecks は異常だったチェックの名前を返します
+func failedHealthChecks(status *health.HealthStatus) []string {
+	var failed []string
+	for _, name := range sortedHealthChecks(status) {
+		if !status.Checks[name].Status {
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+func sortedHealthChecks(status *health.HealthStatus) []string {
+	names := make([]string, 0, len(status.Checks))
+	for name := range status.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	healthCmd.Flags().BoolVar(&healthCheckAPI, "check-api", false, "keruta APIとの接続を確認")
+	healthCmd.Flags().BoolVar(&healthCheckAuth, "check-auth", false, "認証付きエンドポイントを呼び出してAPIトークンの有効性を確認")
+	healthCmd.Flags().BoolVar(&healthCheckDisk, "check-disk", false, "成果物ディレクトリと作業ディレクトリの空き容量を確認")
+	healthCmd.Flags().BoolVar(&healthCheckMemory, "check-memory", false, "システムの使用可能なメモリを確認")
+	healthCmd.Flags().BoolVar(&healthCheckTools, "check-tools", false, "claude, git, tmuxのコマンドが存在するかを確認")
+	healthCmd.Flags().BoolVar(&healthJSON, "json", false, "結果をJSONで出力")
+	defaults := health.DefaultThresholds()
+	healthCmd.Flags().Uint64Var(&healthMinFreeDisk, "min-free-disk", defaults.MinFreeDisk/megabyte, "ディスクの空き容量がこれを下回ると異常とみなす（MB）")
+	healthCmd.Flags().Uint64Var(&healthMinMemory, "min-free-memory", defaults.MinAvailableMemory/megabyte, "使用可能なメモリがこれを下回ると異常とみなす（MB）")
+	healthCmd.Flags().Float64Var(&healthMinFreeDiskPercent, "min-free-disk-percent", defaults.MinFreeDiskPercent, "ディスクの空き容量の割合がこれを下回ると異常とみなす（%）")
+	healthCmd.Flags().Float64Var(&healthMinMemoryPercent, "min-free-memory-percent", defaults.MinAvailableMemoryPercent, "使用可能なメモリの割合がこれを下回ると異常とみなす（%）")
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"keruta-agent/pkg/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHealthTest(t *testing.T) *bytes.Buffer {
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	t.Setenv("KERUTA_WORKING_DIR", t.TempDir())
+
+	var out bytes.Buffer
+	healthCmd.SetOut(&out)
+	t.Cleanup(func() {
+		healthCmd.SetOut(nil)
+		healthCheckAPI, healthCheckAuth, healthCheckDisk, healthCheckMemory, healthCheckTools = false, false, false, false, false
+		healthJSON = false
+		defaults := health.DefaultThresholds()
+		healthMinFreeDisk = defaults.MinFreeDisk / megabyte
+		healthMinMemory = defaults.MinAvailableMemory / megabyte
+		healthMinFreeDiskPercent = defaults.MinFreeDiskPercent
+		healthMinMemoryPercent = defaults.MinAvailableMemoryPercent
+	})
+	return &out
+}
+
+func TestRunHealthSelectedChecksJSON(t *testing.T) {
+	out := setupHealthTest(t)
+	healthCheckAPI = true
+	healthCheckDisk = true
+	healthJSON = true
+	// 実行環境の空き容量に依存しないよう、閾値を小さくする
+	healthMinFreeDisk = 1
+	healthMinFreeDiskPercent = 0
+
+	require.NoError(t, runHealth(healthCmd, nil))
+
+	var status health.HealthStatus
+	require.NoError(t, json.Unmarshal(out.Bytes(), &status))
+	assert.True(t, status.Overall)
+	// 指定したチェックだけを実行する
+	assert.Len(t, status.Checks, 2)
+	assert.Equal(t, "ディスク容量は十分です", status.Checks["disk"].Message)
+}
+
+func TestRunHealthWithoutTaskIDs(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+	out := setupHealthTest(t)
+	healthJSON = true
+	// 実行環境の空き容量とメモリに依存しないよう、閾値を小さくする
+	healthMinFreeDisk, healthMinFreeDiskPercent = 1, 0
+	healthMinMemory, healthMinMemoryPercent = 1, 0
+
+	// Podのプローブとして、タスク・セッション・ワークスペースのIDなしで実行する
+	for _, name := range []string{"KERUTA_TASK_ID", "KERUTA_SESSION_ID", "KERUTA_WORKSPACE_ID", "CODER_WORKSPACE_ID", "CODER_WORKSPACE_NAME"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	bin := t.TempDir()
+	for _, tool := range []string{"claude", "git", "tmux"} {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, tool), []byte("#!/bin/sh\necho 1.0.0\n"), 0755))
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	require.NoError(t, runHealth(healthCmd, nil))
+
+	var status health.HealthStatus
+	require.NoError(t, json.Unmarshal(out.Bytes(), &status))
+	assert.True(t, status.Overall)
+	assert.True(t, status.Checks["auth"].Status)
+	assert.Contains(t, status.Checks["auth"].Message, "スキップしました")
+	assert.True(t, status.Checks["config"].Status)
+}
+
+func TestRunHealthUnhealthy(t *testing.T) {
+	out := setupHealthTest(t)
+	healthCheckDisk = true
+	healthMinFreeDisk = 1 << 40
+
+	err := runHealth(healthCmd, nil)
+	require.Error(t, err)
+	assert.Equal(t, ExitCodeUnhealthy, ExitCode(err))
+	assert.Contains(t, out.String(), "[NG] disk: ディスクの空き容量が不足しています")
+}
+
+func TestRootRequiresTaskIDExceptOptionalCommands(t *testing.T) {
+	t.Setenv("KERUTA_TASK_ID", "")
+	os.Unsetenv("KERUTA_TASK_ID")
+
+	// 引数に"health"が含まれていても、タスクのコマンドではタスクIDが必要
+	err := rootCmd.PersistentPreRunE(logCmd, []string{"INFO", "health"})
+	require.Error(t, err)
+	assert.Equal(t, ExitCodeUsageError, ExitCode(err))
+
+	assert.NoError(t, rootCmd.PersistentPreRunE(healthCmd, nil))
+	assert.NoError(t, rootCmd.PersistentPreRunE(daemonCmd, nil))
+}
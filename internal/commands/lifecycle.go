@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"os"
+
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// start/success/fail/progress コマンド共通のフラグ
+	statusMessage    string
+	noFailOnAPIError bool
+)
+
+// addLifecycleFlags はライフサイクルコマンド共通のフラグを追加します
+func addLifecycleFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&statusMessage, "message", "", "ステータスと共に送信するメッセージ")
+	cmd.Flags().BoolVar(&noFailOnAPIError, "no-fail-on-api-error", false, "API呼び出しに失敗しても終了コード0で終了する（環境変数KERUTA_NO_FAIL_ON_API_ERRORでも指定可能）")
+}
+
+// lifecycleNoArgs は位置引数を受け付けないコマンドの引数検証です
+func lifecycleNoArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return newUsageError("%sコマンドは引数を受け付けません: %v", cmd.Name(), args)
+	}
+	return nil
+}
+
+// requireTaskID はタスクIDを取得し、未設定の場合は引数エラーを返します
+func requireTaskID() (string, error) {
+	taskID := config.GetTaskID()
+	if taskID == "" {
+		return "", newUsageError("タスクIDが指定されていません。--task-idまたは環境変数KERUTA_TASK_IDを設定してください")
+	}
+	return taskID, nil
+}
+
+// messageOrDefault は--messageが指定されていない場合にデフォルトのメッセージを返します
+func messageOrDefault(defaultMessage string) string {
+	if statusMessage != "" {
+		return statusMessage
+	}
+	return defaultMessage
+}
+
+// handleLifecycleAPIError はAPI呼び出しのエラーを終了コード付きのエラーに変換します
+// --no-fail-on-api-error が指定されている場合は警告を出力して正常終了扱いにします
+func handleLifecycleAPIError(cmd *cobra.Command, err error) error {
+	if noFailOnAPIError || os.Getenv("KERUTA_NO_FAIL_ON_API_ERROR") == "true" {
+		logger.WithTaskID().WithError(err).WithField("command", cmd.Name()).Warn("API呼び出しに失敗しましたが、--no-fail-on-api-errorが指定されているため処理を継続します")
+		return nil
+	}
+
+	// API障害は使い方の誤りではないため、ヘルプは表示しない
+	cmd.SilenceUsage = true
+	return newAPIError(err)
+}
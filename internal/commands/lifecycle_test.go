@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLifecycleTest(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config.GlobalConfig = &config.Config{
+		API: config.APIConfig{
+			URL:     server.URL,
+			Token:   "test-token",
+			Timeout: 5 * time.Second,
+		},
+		Artifacts: config.ArtifactsConfig{
+			Directory: t.TempDir(),
+		},
+	}
+
+	os.Setenv("KERUTA_TASK_ID", "lifecycle-task-123")
+	t.Cleanup(func() {
+		os.Unsetenv("KERUTA_TASK_ID")
+		statusMessage = ""
+		noFailOnAPIError = false
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitCodeSuccess, ExitCode(nil))
+	assert.Equal(t, ExitCodeError, ExitCode(errors.New("plain error")))
+	assert.Equal(t, ExitCodeUsageError, ExitCode(newUsageError("bad argument")))
+	assert.Equal(t, ExitCodeAPIError, ExitCode(newAPIError(errors.New("api down"))))
+}
+
+func TestParseProgress(t *testing.T) {
+	progress, err := parseProgress("50")
+	require.NoError(t, err)
+	assert.Equal(t, 50, progress)
+
+	for _, arg := range []string{"-1", "101", "abc"} {
+		_, err := parseProgress(arg)
+		assert.Error(t, err, arg)
+		assert.Equal(t, ExitCodeUsageError, ExitCode(err), arg)
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	var received api.TaskUpdateRequest
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/tasks/lifecycle-task-123/status", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	statusMessage = "データ処理中..."
+	err := runProgress(progressCmd, []string{"75"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, api.TaskStatusProcessing, received.Status)
+	assert.Equal(t, 75, received.Progress)
+	assert.Equal(t, "データ処理中...", received.Message)
+}
+
+func TestRunSuccessAPIError(t *testing.T) {
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	err := runSuccess(successCmd, nil)
+	assert.Error(t, err)
+	assert.Equal(t, ExitCodeAPIError, ExitCode(err))
+
+	// --no-fail-on-api-error 指定時は正常終了扱い
+	noFailOnAPIError = true
+	err = runSuccess(successCmd, nil)
+	assert.NoError(t, err)
+}
+
+func TestRunStartWithoutTaskID(t *testing.T) {
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("タスクIDがない場合はAPIを呼び出さないべき")
+	})
+	os.Unsetenv("KERUTA_TASK_ID")
+
+	err := runStart(startCmd, nil)
+	assert.Error(t, err)
+	assert.Equal(t, ExitCodeUsageError, ExitCode(err))
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// logStdinBatchSize は--stdinで一度に送信するログの最大件数です
+	logStdinBatchSize = 100
+	// logStdinFlushInterval は--stdinでバッファ済みのログを送信する間隔です
+	logStdinFlushInterval = time.Second
+)
+
+var (
+	// log コマンドのフラグ
+	logFields []string
+	logStdin  bool
+
+	// logInput は--stdinで読み取る入力です（テストで差し替えます）
+	logInput io.Reader = os.Stdin
+)
+
+// logCmd はタスクに構造化ログを送信するコマンドです
+var logCmd = &cobra.Command{
+	Use:   "log <level> [message...]",
+	Short: "タスクに構造化ログを送信",
+	Long: `タスクにログを送信します。レベルはDEBUG, INFO, WARN, ERRORのいずれかです。
+--fieldでkey=value形式のフィールドを付与できます（複数指定可能）。
+--stdinを指定すると、標準入力の各行を1件のログとして送信します。
+
+終了コード:
+  0  正常終了（--no-fail-on-api-error指定時はAPIエラーでも0）
+  1  予期しないエラー
+  2  引数・タスクIDの指定誤り
+  3  keruta APIの呼び出しに失敗`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return newUsageError("ログレベルを指定してください（DEBUG, INFO, WARN, ERROR）")
+		}
+		if logStdin && len(args) > 1 {
+			return newUsageError("--stdinを指定した場合はメッセージを指定できません")
+		}
+		if !logStdin && len(args) < 2 {
+			return newUsageError("メッセージを指定するか、--stdinを指定してください")
+		}
+		return nil
+	},
+	RunE: runLog,
+	Example: `  keruta log INFO "データベースクエリを実行中..."
+  keruta log WARN "リトライします" --field attempt=2 --field table=users
+  python main.py 2>&1 | keruta log INFO --stdin --field step=main`,
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	level, err := parseLogLevel(args[0])
+	if err != nil {
+		return err
+	}
+	fields, err := parseLogFields(logFields)
+	if err != nil {
+		return err
+	}
+
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	apiClient := api.NewClient()
+	if logStdin {
+		var readErr error
+		err, readErr = streamLogLines(apiClient, taskID, level, fields, logInput)
+		if readErr != nil {
+			return fmt.Errorf("stdin read failed: %w", readErr)
+		}
+	} else {
+		err = sendLogLines(apiClient, taskID, []api.LogRequest{newScriptLog(taskID, level, strings.Join(args[1:], " "), fields)})
+	}
+	if err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("log submission failed: %w", err))
+	}
+	return nil
+}
+
+// parseLogLevel はログレベルの引数を解析します
+func parseLogLevel(arg string) (string, error) {
+	switch level := strings.ToUpper(strings.TrimSpace(arg)); level {
+	case "DEBUG", "INFO", "ERROR":
+		return level, nil
+	case "WARN", "WARNING":
+		return "WARN", nil
+	default:
+		return "", newUsageError("ログレベルはDEBUG, INFO, WARN, ERRORのいずれかを指定してください: %s", arg)
+	}
+}
+
+// parseLogFields は--fieldのkey=value形式の値を解析します
+func parseLogFields(values []string) (map[string]interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	fields := make(map[string]interface{}, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, newUsageError("--fieldはkey=value形式で指定してください: %s", value)
+		}
+		fields[key] = val
+	}
+	return fields, nil
+}
+
+// newScriptLog はタスクのスクリプトから送信するログを作成します
+func newScriptLog(taskID, level, message string, fields map[string]interface{}) api.LogRequest {
+	log := api.NewLogRequest(api.LogSourceScript, level, message)
+	log.Fields = fields
+	return log
+}
+
+// sendLogLines はログをまとめて送信します
+// 1件のみの場合や、サーバーが一括送信に対応していない場合は、送信元とフィールドを保ったまま/logsに送信されます
+func sendLogLines(apiClient api.KerutaAPI, taskID string, logs []api.LogRequest) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return apiClient.SendLogBatch(taskID, logs)
+}
+
+// streamLogLines は入力の各行をログとして送信し、最初の送信エラーと読み取りエラーを返します
+// 書き込み側のプロセスを止めないよう、送信に失敗しても入力は最後まで読み取ります
+func streamLogLines(apiClient api.KerutaAPI, taskID, level string, fields map[string]interface{}, input io.Reader) (sendErr, readErr error) {
+	lines := make(chan api.LogRequest, logStdinBatchSize)
+	readErrs := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(input)
+		for {
+			line, err := reader.ReadString('\n')
+			line = strings.TrimRight(line, "\r\n")
+			if line != "" {
+				// 時刻とシーケンス番号は読み取った時点で付与する
+				lines <- newScriptLog(taskID, level, line, fields)
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErrs <- err
+				}
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(logStdinFlushInterval)
+	defer ticker.Stop()
+
+	var batch []api.LogRequest
+	flush := func() {
+		if err := sendLogLines(apiClient, taskID, batch); err != nil {
+			logger.WithTaskID().WithError(err).WithField("count", len(batch)).Warning("ログの送信に失敗しました")
+			if sendErr == nil {
+				sendErr = err
+			}
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case log, ok := <-lines:
+			if !ok {
+				flush()
+				select {
+				case err := <-readErrs:
+					return sendErr, err
+				default:
+					return sendErr, nil
+				}
+			}
+			batch = append(batch, log)
+			if len(batch) >= logStdinBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func init() {
+	logCmd.Flags().StringArrayVar(&logFields, "field", nil, "ログに付与するフィールド（key=value形式、複数指定可能）")
+	logCmd.Flags().BoolVar(&logStdin, "stdin", false, "標準入力の各行をログとして送信する")
+	logCmd.Flags().BoolVar(&noFailOnAPIError, "no-fail-on-api-error", false, "API呼び出しに失敗しても終了コード0で終了する（環境変数KERUTA_NO_FAIL_ON_API_ERRORでも指定可能）")
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"keruta-agent/internal/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLogTest はログを受け取るテスト用のAPIサーバーを用意します
+// batchがfalseの場合は、一括送信に対応していないサーバーとして/logs/batchに404を返します
+func setupLogTest(t *testing.T, batch bool) func() []api.LogRequest {
+	var (
+		mu       sync.Mutex
+		received []api.LogRequest
+	)
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v1/tasks/lifecycle-task-123/logs/batch":
+			if !batch {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var body api.LogBatchRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			received = append(received, body.Logs...)
+		case "/api/v1/tasks/lifecycle-task-123/logs":
+			var log api.LogRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&log))
+			received = append(received, log)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	t.Cleanup(func() {
+		logFields = nil
+		logStdin = false
+		logInput = os.Stdin
+	})
+	return func() []api.LogRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]api.LogRequest(nil), received...)
+	}
+}
+
+func TestRunLogWithFields(t *testing.T) {
+	received := setupLogTest(t, true)
+
+	logFields = []string{"table=users", "attempt=2"}
+	err := runLog(logCmd, []string{"warning", "データベースクエリを", "実行中..."})
+	require.NoError(t, err)
+
+	logs := received()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "WARN", logs[0].Level)
+	assert.Equal(t, "データベースクエリを 実行中...", logs[0].Message)
+	assert.Equal(t, api.LogSourceScript, logs[0].Source)
+	assert.Equal(t, map[string]interface{}{"table": "users", "attempt": "2"}, logs[0].Fields)
+	assert.NotZero(t, logs[0].Sequence)
+}
+
+func TestRunLogStdin(t *testing.T) {
+	// 一括送信に対応していないサーバーには1件ずつ送信する
+	for _, batch := range []bool{true, false} {
+		t.Run(fmt.Sprintf("batch=%t", batch), func(t *testing.T) {
+			received := setupLogTest(t, batch)
+
+			logStdin = true
+			logFields = []string{"step=main"}
+			logInput = strings.NewReader("line 1\n\nline 2\r\nline 3")
+			require.NoError(t, runLog(logCmd, []string{"INFO"}))
+
+			logs := received()
+			require.Len(t, logs, 3)
+			for i, log := range logs {
+				assert.Equal(t, "INFO", log.Level)
+				assert.Equal(t, api.LogSourceScript, log.Source)
+				assert.Equal(t, "main", log.Fields["step"])
+				if i > 0 {
+					assert.Greater(t, log.Sequence, logs[i-1].Sequence)
+				}
+			}
+			assert.Equal(t, "line 3", logs[2].Message)
+		})
+	}
+}
+
+func TestRunLogInvalidArguments(t *testing.T) {
+	setupLogTest(t, true)
+
+	err := runLog(logCmd, []string{"TRACE", "message"})
+	assert.Equal(t, ExitCodeUsageError, ExitCode(err))
+
+	logFields = []string{"missing-separator"}
+	err = runLog(logCmd, []string{"INFO", "message"})
+	assert.Equal(t, ExitCodeUsageError, ExitCode(err))
+}
+
+func TestRunLogAPIError(t *testing.T) {
+	setupLifecycleTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	err := runLog(logCmd, []string{"INFO", "message"})
+	assert.Equal(t, ExitCodeAPIError, ExitCode(err))
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+// progressCmd はタスクの進捗率を報告するコマンドです
+var progressCmd = &cobra.Command{
+	Use:   "progress <percentage>",
+	Short: "タスクの進捗率を報告",
+	Long: `タスクの進捗率（0-100）を報告します。ステータスはIN_PROGRESSのまま更新されます。
+
+終了コード:
+  0  正常終了（--no-fail-on-api-error指定時はAPIエラーでも0）
+  1  予期しないエラー
+  2  引数・タスクIDの指定誤り
+  3  keruta APIの呼び出しに失敗`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return newUsageError("進捗率を1つ指定してください（0-100）")
+		}
+		return nil
+	},
+	RunE:    runProgress,
+	Example: `  keruta progress 50 --message "データ処理中..."`,
+}
+
+func runProgress(cmd *cobra.Command, args []string) error {
+	progress, err := parseProgress(args[0])
+	if err != nil {
+		return err
+	}
+
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	apiClient := api.NewClient()
+	if err := apiClient.UpdateTaskStatus(taskID, api.TaskStatusProcessing, statusMessage, progress, ""); err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("task progress update failed: %w", err))
+	}
+
+	logger.WithTaskID().WithField("progress", progress).Info("タスクの進捗率を報告しました")
+	return nil
+}
+
+// parseProgress は進捗率の引数を解析し、0-100の範囲であることを確認します
+func parseProgress(arg string) (int, error) {
+	progress, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, newUsageError("進捗率は整数で指定してください: %s", arg)
+	}
+	if progress < 0 || progress > 100 {
+		return 0, newUsageError("進捗率は0から100の範囲で指定してください: %d", progress)
+	}
+	return progress, nil
+}
+
+func init() {
+	addLifecycleFlags(progressCmd)
+}
@@ -9,6 +9,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// taskIDAnnotation はコマンドがタスクIDを必要とするかどうかを表すアノテーションのキーです
+// 値がtaskIDOptionalのコマンドはタスクIDを指定せずに実行できます
+const (
+	taskIDAnnotation = "keruta.task-id"
+	taskIDOptional   = "optional"
+)
+
 var (
 	// グローバルフラグ
 	verbose bool
@@ -21,7 +28,7 @@ var rootCmd = &cobra.Command{
 	Short: "keruta-agent - Kubernetes Pod内でタスクを実行するCLIツール",
 	Long: `keruta-agentは、kerutaシステムによってKubernetes Jobとして実行されるPod内で動作するCLIツールです。
 タスクの実行状況をkeruta APIサーバーに報告し、成果物の保存、ログの収集、エラーハンドリングなどの機能を提供します。`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// ログレベルの設定
 		if verbose {
 			logrus.SetLevel(logrus.DebugLevel)
@@ -34,7 +41,15 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
+		// タスクIDが必要なコマンドは、APIを呼び出す前にタスクIDの指定を確認する
+		if cmd.Annotations[taskIDAnnotation] != taskIDOptional {
+			if _, err := requireTaskID(); err != nil {
+				return err
+			}
+		}
+
 		logger.WithTaskID().Debug("keruta-agentを開始しました")
+		return nil
 	},
 }
 
@@ -50,6 +65,13 @@ func init() {
 
 	// サブコマンドの追加
 	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(successCmd)
+	rootCmd.AddCommand(failCmd)
+	rootCmd.AddCommand(progressCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(artifactCmd)
+	rootCmd.AddCommand(healthCmd)
 
 	// ヘルプテンプレートの設定
 	rootCmd.SetHelpTemplate(`{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces}}
@@ -57,7 +79,24 @@ func init() {
 {{end}}{{if or .Runnable .HasSubCommands}}{{.UsageString}}{{end}}`)
 
 	// 使用例の設定
-	rootCmd.Example = `  # デーモンモードで起動
+	rootCmd.Example = `  # タスクの開始・進捗・完了を報告
+  keruta start
+  keruta progress 50 --message "データ処理中..."
+  keruta success --message "データ処理が完了しました"
+
+  # タスクにログを送信
+  keruta log INFO "データベースクエリを実行中..." --field table=users
+
+  # 成果物をアップロード
+  keruta artifact add ./output/report.pdf --description "月次レポート"
+
+  # 実行環境のヘルスチェック
+  keruta health --json
+
+  # タスクの失敗を報告
+  keruta fail --message "データベース接続に失敗しました" --error-code DB_CONNECTION_ERROR
+
+  # デーモンモードで起動
   keruta daemon
 
   # デーモンモードでポート指定
@@ -114,7 +114,7 @@ func extractSessionIDFromWorkspaceName(workspaceName string) string {
 }
 
 // resolveFullSessionID は部分的なセッションIDまたはワークスペース名から完全なUUIDを取得します
-func resolveFullSessionID(apiClient *api.Client, partialID string, logger *logrus.Entry) string {
+func resolveFullSessionID(apiClient api.KerutaAPI, partialID string, logger *logrus.Entry) string {
 	// 既に完全なUUID形式の場合はそのまま返す
 	if isValidUUIDFormat(partialID) {
 		return partialID
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+// startCmd はタスクの開始を報告するコマンドです
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "タスクの実行開始を報告",
+	Long: `タスクの実行を開始し、ステータスをIN_PROGRESSに更新します。
+
+終了コード:
+  0  正常終了（--no-fail-on-api-error指定時はAPIエラーでも0）
+  1  予期しないエラー
+  2  引数・タスクIDの指定誤り
+  3  keruta APIの呼び出しに失敗`,
+	Args: lifecycleNoArgs,
+	RunE: runStart,
+	Example: `  # タスクの開始を報告
+  keruta start
+
+  # メッセージ付きで開始を報告
+  keruta start --message "データ処理を開始します"`,
+}
+
+func runStart(cmd *cobra.Command, _ []string) error {
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	apiClient := api.NewClient()
+	if statusMessage != "" {
+		err = apiClient.UpdateTaskStatus(taskID, api.TaskStatusProcessing, statusMessage, 0, "")
+	} else {
+		err = apiClient.StartTask(taskID)
+	}
+	if err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("task start notification failed: %w", err))
+	}
+
+	logger.WithTaskID().Info("タスクの開始を報告しました")
+	return nil
+}
+
+func init() {
+	addLifecycleFlags(startCmd)
+}
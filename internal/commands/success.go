@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+// successCmd はタスクの成功を報告するコマンドです
+var successCmd = &cobra.Command{
+	Use:   "success",
+	Short: "タスクの成功を報告",
+	Long: `タスクの成功を報告し、ステータスをCOMPLETEDに更新します。
+
+終了コード:
+  0  正常終了（--no-fail-on-api-error指定時はAPIエラーでも0）
+  1  予期しないエラー
+  2  引数・タスクIDの指定誤り
+  3  keruta APIの呼び出しに失敗`,
+	Args: lifecycleNoArgs,
+	RunE: runSuccess,
+	Example: `  # 処理完了後
+  keruta success --message "データ処理が正常に完了しました"`,
+}
+
+func runSuccess(cmd *cobra.Command, _ []string) error {
+	taskID, err := requireTaskID()
+	if err != nil {
+		return err
+	}
+
+	apiClient := api.NewClient()
+	if err := apiClient.SuccessTask(taskID, messageOrDefault("タスクが正常に完了しました")); err != nil {
+		return handleLifecycleAPIError(cmd, fmt.Errorf("task success notification failed: %w", err))
+	}
+
+	logger.WithTaskID().Info("タスクの成功を報告しました")
+	return nil
+}
+
+func init() {
+	addLifecycleFlags(successCmd)
+}
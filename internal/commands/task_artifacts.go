@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/pkg/artifacts"
+
+	"github.com/sirupsen/logrus"
+)
+
+// artifactArchiveKeep はローテーションした成果物ディレクトリを残すタスク数です
+const artifactArchiveKeep = 5
+
+// uploadTaskArtifacts は成果物ディレクトリのファイルを収集してタスクにアップロードし、ディレクトリをローテーションします
+// タスクのParametersのartifacts_extensions・artifacts_include・artifacts_excludeで収集するファイルを絞り込めます
+// 件数がしきい値を超える場合やartifacts_modeにbundleが指定された場合は、1つのアーカイブにまとめてアップロードします
+// アップロードに失敗したファイルがあってもほかのファイルのアップロードは続け、失敗した件数をエラーとして返します
+func uploadTaskArtifacts(apiClient api.KerutaAPI, task *api.Task, logger *logrus.Entry) error {
+	taskID := task.ID
+	manager := artifacts.NewManager()
+	defer rotateTaskArtifacts(manager, taskID, logger)
+
+	if err := manager.SetFilter(artifacts.FilterFromParameters(artifacts.ConfigFilter(), task.Parameters)); err != nil {
+		logger.WithError(err).Warn("タスクで指定された成果物のパターンが不正なため、設定のパターンを使用します")
+	}
+
+	collected, err := manager.CollectArtifacts()
+	if err != nil {
+		return fmt.Errorf("artifact collection failed: %w", err)
+	}
+	if len(collected) == 0 {
+		return nil
+	}
+
+	options, err := artifacts.BundleOptionsFromParameters(artifacts.ConfigBundleOptions(), task.Parameters)
+	if err != nil {
+		logger.WithError(err).Warn("タスクで指定された成果物のアップロード方法が不正なため、設定値を使用します")
+	}
+	if options.ShouldBundle(len(collected)) {
+		uploaded, err := uploadArtifactBundle(apiClient, taskID, collected, options, logger)
+		if uploaded {
+			return err
+		}
+		logger.WithError(err).Warn("成果物をアーカイブにまとめられなかったため、ファイルごとにアップロードします")
+	}
+
+	var failed []string
+	for _, artifact := range collected {
+		description := manager.GetArtifactDescription(artifact)
+		if err := apiClient.UploadArtifact(taskID, artifact.Path, description); err != nil {
+			logger.WithError(err).WithField("artifact", artifact.Name).Warn("成果物のアップロードに失敗しました")
+			failed = append(failed, artifact.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d artifact uploads failed: %v", len(failed), len(collected), failed)
+	}
+	logger.WithField("count", len(collected)).Info("📦 成果物をアップロードしました")
+	return nil
+}
+
+// uploadArtifactBundle は成果物を1つのアーカイブにまとめてアップロードします
+// アーカイブを作成できなかった場合はuploadedがfalseになり、呼び出し側でファイルごとのアップロードに切り替えます
+func uploadArtifactBundle(apiClient api.KerutaAPI, taskID string, collected []artifacts.Artifact, options artifacts.BundleOptions, logger *logrus.Entry) (uploaded bool, err error) {
+	dir, err := os.MkdirTemp("", "keruta-bundle-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+
+	bundlePath := filepath.Join(dir, options.FileName("artifacts-"+taskID))
+	manifest, err := artifacts.CreateBundle(collected, options.Format, bundlePath)
+	if err != nil {
+		return false, err
+	}
+
+	description := fmt.Sprintf("成果物アーカイブ（%d件）", len(manifest.Files))
+	if err := apiClient.UploadArtifact(taskID, bundlePath, description); err != nil {
+		return true, fmt.Errorf("artifact bundle upload failed: %w", err)
+	}
+	logger.WithFields(logrus.Fields{
+		"count":  len(manifest.Files),
+		"format": options.Format,
+	}).Info("📦 成果物をアーカイブにまとめてアップロードしました")
+	return true, nil
+}
+
+// rotateTaskArtifacts は成果物ディレクトリをローテーションし、次のタスクで同じファイルをアップロードしないようにします
+func rotateTaskArtifacts(manager *artifacts.Manager, taskID string, logger *logrus.Entry) {
+	if err := manager.RotateArtifacts(taskID, artifactArchiveKeep); err != nil {
+		logger.WithError(err).Warn("成果物ディレクトリのローテーションに失敗しました")
+	}
+}
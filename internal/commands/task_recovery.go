@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/executor"
+	"keruta-agent/internal/metrics"
+	"keruta-agent/internal/state"
+
+	"github.com/sirupsen/logrus"
+)
+
+// taskState は実行中のタスクを記録する状態ファイルです（runDaemon実行中のみ設定されます）
+var taskState *state.Store
+
+// エージェントの再起動時に実行中だったタスクの扱い
+const (
+	// recoveryResume はタスクを再起動後すぐに再開します
+	recoveryResume = "resume"
+	// recoveryRequeue はタスクをPENDINGに戻し、次のポーリングで実行します
+	recoveryRequeue = "requeue"
+	// recoveryFail はタスクを失敗として通知します
+	recoveryFail = "fail"
+)
+
+// agentRestartedCode はエージェントの再起動によって中断したタスクの失敗を通知するエラーコードです
+const agentRestartedCode = "AGENT_RESTARTED"
+
+// orphanPollInterval は前回のタスクのプロセスが終了したかどうかを確認する間隔です
+var orphanPollInterval = 100 * time.Millisecond
+
+// recordTaskStart はタスクの実行開始を状態ファイルに記録します
+func recordTaskStart(task *api.Task, source string, logger *logrus.Entry) {
+	if taskState == nil {
+		return
+	}
+	err := taskState.Begin(state.TaskRecord{
+		TaskID:    task.ID,
+		SessionID: task.SessionID,
+		Source:    source,
+	})
+	if err != nil {
+		logger.WithError(err).Warn("実行中のタスクを状態ファイルに記録できませんでした")
+	}
+}
+
+// recordTaskBranch は実行中のタスクのブランチを状態ファイルに記録します
+func recordTaskBranch(taskID, branch string, logger *logrus.Entry) {
+	if taskState == nil {
+		return
+	}
+	if err := taskState.SetBranch(taskID, branch); err != nil {
+		logger.WithError(err).Warn("タスクのブランチを状態ファイルに記録できませんでした")
+	}
+}
+
+// recordTaskProcessGroup は実行中のタスクの子プロセスのプロセスグループを状態ファイルに記録します
+func recordTaskProcessGroup(taskID string, pgid int, logger *logrus.Entry) {
+	if taskState == nil {
+		return
+	}
+	if err := taskState.SetProcessGroup(taskID, pgid); err != nil {
+		logger.WithError(err).Warn("タスクのプロセスグループを状態ファイルに記録できませんでした")
+	}
+}
+
+// recordTaskFinish はタスクの終了を状態ファイルに記録します
+func recordTaskFinish(taskID string, logger *logrus.Entry) {
+	if taskState == nil {
+		return
+	}
+	if err := taskState.Finish(taskID); err != nil {
+		logger.WithError(err).Warn("タスクの終了を状態ファイルに記録できませんでした")
+	}
+}
+
+// recoverInFlightTask は前回の実行で終了できなかったタスクをサーバーの状態と照合し、再開・再キュー・失敗の通知を行います
+// サーバーに問い合わせできなかった場合はエラーを返します。記録は残るため、次回の呼び出しで再度照合します
+func recoverInFlightTask(ctx context.Context, apiClient api.KerutaAPI, logger *logrus.Entry) error {
+	if taskState == nil {
+		return nil
+	}
+	record := taskState.Current()
+	if record == nil {
+		return nil
+	}
+
+	log := logger.WithFields(logrus.Fields{
+		"task_id":    record.TaskID,
+		"session_id": record.SessionID,
+		"branch":     record.Branch,
+		"pid":        record.PID,
+		"started_at": record.StartedAt.Format(time.RFC3339),
+		"restarts":   record.Restarts,
+	})
+	if record.OwnerRunning() {
+		log.Warn("実行中だったタスクを記録したエージェントがまだ動作しているため、タスクの復旧をスキップします")
+		return nil
+	}
+
+	// 前回のタスクのプロセスが残ったまま再開すると、同じ作業ディレクトリで2つのタスクが動作してしまう
+	if err := stopOrphanedProcessGroup(record, log); err != nil {
+		return err
+	}
+
+	task, err := apiClient.GetTask(record.TaskID)
+	if err != nil {
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			log.Info("実行中だったタスクはサーバーに存在しないため、記録を破棄します")
+			recordTaskFinish(record.TaskID, logger)
+			return nil
+		}
+		return fmt.Errorf("in-flight task lookup failed: %w", err)
+	}
+
+	log = log.WithField("status", task.Status)
+	switch {
+	case task.Status.IsTerminal():
+		log.Info("実行中だったタスクは既に終了しているため、記録を破棄します")
+		recordTaskFinish(record.TaskID, logger)
+		return nil
+	case task.Status == api.TaskStatusPending:
+		// 開始を通知する前に停止した場合は、次のポーリングでそのまま実行する
+		if _, err := taskState.Restarted(record.TaskID); err != nil {
+			log.WithError(err).Warn("タスクの再起動の回数を状態ファイルに記録できませんでした")
+		}
+		log.Info("実行中だったタスクはPENDINGのため、次のポーリングで実行します")
+		return nil
+	}
+
+	policy := config.GetTaskRecoveryPolicy()
+	switch policy {
+	case recoveryResume, recoveryRequeue, recoveryFail:
+	default:
+		log.WithField("policy", policy).Warn("不明なタスクの復旧方法のため、デフォルトの方法を使用します")
+		policy = config.DefaultTaskRecoveryPolicy
+	}
+
+	reason := ""
+	switch {
+	case policy == recoveryFail:
+		reason = "再起動時に実行中だったタスクは失敗として扱う設定です"
+	case record.Source == taskSourceHTTP:
+		reason = "HTTP経由のタスクはスクリプトが保存されていないため再開できません"
+	case record.Restarts >= config.GetTaskMaxRestarts():
+		reason = fmt.Sprintf("再起動の回数が上限（%d回）に達しました", config.GetTaskMaxRestarts())
+	}
+	if reason != "" {
+		log.WithField("reason", reason).Warn("🛑 実行中だったタスクを失敗として通知します")
+		reportTaskFailure(ctx, apiClient, record.TaskID, "エージェントが再起動したため、タスクを中断しました: "+reason, agentRestartedCode, log)
+		metrics.ObserveTask(metrics.TaskFailed, agentRestartedCode, time.Since(record.StartedAt))
+		recordTaskFinish(record.TaskID, logger)
+		return nil
+	}
+
+	if policy == recoveryRequeue {
+		if err := apiClient.UpdateTaskStatus(record.TaskID, api.TaskStatusPending, "エージェントが再起動したため、タスクを再キューしました", 0, ""); err != nil {
+			return fmt.Errorf("in-flight task requeue failed: %w", err)
+		}
+	}
+	if _, err := taskState.Restarted(record.TaskID); err != nil {
+		log.WithError(err).Warn("タスクの再起動の回数を状態ファイルに記録できませんでした")
+	}
+	if policy == recoveryRequeue {
+		log.Info("🔁 実行中だったタスクを再キューしました")
+		return nil
+	}
+
+	log.Info("🔁 実行中だったタスクを再開します")
+	if err := executeTask(ctx, apiClient, task, logger); err != nil {
+		log.WithError(err).Error("再開したタスクの実行に失敗しました")
+	}
+	return nil
+}
+
+// stopOrphanedProcessGroup は前回のエージェントが起動したタスクのプロセスが残っている場合に停止します
+// SIGTERMを送信して猶予時間だけ終了を待ち、終了しない場合はSIGKILLを送信します。それでも終了しない場合はエラーを返します
+func stopOrphanedProcessGroup(record *state.TaskRecord, log *logrus.Entry) error {
+	if !record.ProcessGroupRunning() {
+		return nil
+	}
+	log = log.WithField("pgid", record.ProcessGroup)
+	log.Warn("🛑 前回のタスクのプロセスが残っているため、停止してから復旧します")
+
+	grace := config.GetKillGracePeriod()
+	if err := executor.TerminateProcessGroup(record.ProcessGroup); err != nil {
+		return fmt.Errorf("orphaned process group termination failed: %w", err)
+	}
+	if waitProcessGroupExit(record, grace) {
+		return nil
+	}
+
+	log.Warn("猶予時間内に終了しなかったため、前回のタスクのプロセスにSIGKILLを送信します")
+	if err := executor.KillProcessGroup(record.ProcessGroup); err != nil {
+		return fmt.Errorf("orphaned process group kill failed: %w", err)
+	}
+	if !waitProcessGroupExit(record, grace) {
+		return fmt.Errorf("orphaned process group %d is still running", record.ProcessGroup)
+	}
+	return nil
+}
+
+// waitProcessGroupExit はプロセスグループのプロセスが終了するまで最大timeoutだけ待ち、終了したかどうかを返します
+func waitProcessGroupExit(record *state.TaskRecord, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for record.ProcessGroupRunning() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(orphanPollInterval)
+	}
+	return true
+}
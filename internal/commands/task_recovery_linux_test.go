@@ -0,0 +1,62 @@
+//go:build linux
+
+package commands
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/state"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverInFlightTaskStopsOrphanedProcesses(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"SIGTERMで終了するプロセス", "sleep 30"},
+		{"SIGTERMを無視するプロセス", "trap '' TERM; sleep 30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// クラッシュしたエージェントが起動し、別のプロセスグループで動作し続けているタスクのプロセス
+			cmd := exec.Command("sh", "-c", tt.script)
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			require.NoError(t, cmd.Start())
+			exited := make(chan struct{})
+			go func() {
+				_ = cmd.Wait()
+				close(exited)
+			}()
+			t.Cleanup(func() {
+				_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+				<-exited
+			})
+
+			server := &recoveryServer{status: api.TaskStatusCompleted}
+			store := setupRecoveryTest(t, server, recoveryResume, state.TaskRecord{TaskID: "crashed-task", Source: taskSourcePoll})
+			config.GlobalConfig.Task.KillGracePeriod = 300 * time.Millisecond
+			require.NoError(t, store.SetProcessGroup("crashed-task", cmd.Process.Pid))
+			require.True(t, store.Current().ProcessGroupRunning())
+
+			require.NoError(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+			select {
+			case <-exited:
+			case <-time.After(5 * time.Second):
+				t.Fatal("前回のタスクのプロセスが停止していません")
+			}
+			assert.Nil(t, store.Current())
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/state"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recoveryServer は復旧時のタスクの照合に使うテスト用のAPIサーバーです
+type recoveryServer struct {
+	mu       sync.Mutex
+	status   api.TaskStatus
+	notFound bool
+	updates  []api.TaskUpdateRequest
+}
+
+func (s *recoveryServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/status"):
+			var req api.TaskUpdateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			s.updates = append(s.updates, req)
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/tasks/crashed-task/script":
+			require.NoError(t, json.NewEncoder(w).Encode(api.ScriptResponse{Success: true, Script: api.Script{Content: "true", Language: "sh"}}))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/tasks/crashed-task":
+			if s.notFound {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(api.Task{ID: "crashed-task", Name: "crashed", Status: s.status}))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+}
+
+func (s *recoveryServer) statuses() []api.TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []api.TaskStatus
+	for _, update := range s.updates {
+		statuses = append(statuses, update.Status)
+	}
+	return statuses
+}
+
+// setupRecoveryTest はクラッシュ前に実行中だったタスクを記録した状態ファイルを用意します
+func setupRecoveryTest(t *testing.T, server *recoveryServer, policy string, record state.TaskRecord) *state.Store {
+	setupLifecycleTest(t, server.handle(t))
+	config.GlobalConfig.Task = config.TaskConfig{RecoveryPolicy: policy, MaxRestarts: 1}
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() {
+		config.GlobalConfig = nil
+	})
+
+	dir := t.TempDir()
+	crashed, err := state.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, crashed.Begin(record))
+
+	store, err := state.Open(dir)
+	require.NoError(t, err)
+	taskState = store
+	t.Cleanup(func() {
+		taskState = nil
+	})
+	return store
+}
+
+func recoveryLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return logrus.NewEntry(logger)
+}
+
+func TestRecoverInFlightTaskWithoutRecord(t *testing.T) {
+	// 状態ファイルがない場合はAPIを呼び出さない
+	assert.NoError(t, recoverInFlightTask(context.Background(), nil, recoveryLogger()))
+
+	store, err := state.Open(t.TempDir())
+	require.NoError(t, err)
+	taskState = store
+	defer func() {
+		taskState = nil
+	}()
+	assert.NoError(t, recoverInFlightTask(context.Background(), nil, recoveryLogger()))
+}
+
+func TestRecoverInFlightTaskAlreadyFinished(t *testing.T) {
+	server := &recoveryServer{status: api.TaskStatusCompleted}
+	store := setupRecoveryTest(t, server, recoveryResume, state.TaskRecord{TaskID: "crashed-task", Source: taskSourcePoll})
+
+	require.NoError(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+	assert.Nil(t, store.Current())
+	assert.Empty(t, server.statuses())
+}
+
+func TestRecoverInFlightTaskDeleted(t *testing.T) {
+	server := &recoveryServer{notFound: true}
+	store := setupRecoveryTest(t, server, recoveryResume, state.TaskRecord{TaskID: "crashed-task", Source: taskSourcePoll})
+
+	require.NoError(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+	assert.Nil(t, store.Current())
+}
+
+func TestRecoverInFlightTaskRequeue(t *testing.T) {
+	server := &recoveryServer{status: api.TaskStatusProcessing}
+	store := setupRecoveryTest(t, server, recoveryRequeue, state.TaskRecord{TaskID: "crashed-task", Source: taskSourcePoll})
+
+	require.NoError(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusPending}, server.statuses())
+	assert.Nil(t, store.Current())
+
+	// 再キューしたタスクを実行するときは再起動の回数を引き継ぐ
+	require.NoError(t, store.Begin(state.TaskRecord{TaskID: "crashed-task"}))
+	assert.Equal(t, 1, store.Current().Restarts)
+}
+
+func TestRecoverInFlightTaskFails(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		source string
+		// restarted は以前にも再起動して再開したタスクかどうかです
+		restarted bool
+	}{
+		{"設定で失敗として扱う", recoveryFail, taskSourcePoll, false},
+		{"HTTP経由のタスク", recoveryResume, taskSourceHTTP, false},
+		{"再起動の回数が上限", recoveryRequeue, taskSourcePoll, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &recoveryServer{status: api.TaskStatusProcessing}
+			record := state.TaskRecord{TaskID: "crashed-task", Source: tt.source}
+			store := setupRecoveryTest(t, server, tt.policy, record)
+			if tt.restarted {
+				// 1回再起動して再開したタスクが、再びクラッシュした
+				_, err := store.Restarted("crashed-task")
+				require.NoError(t, err)
+				require.NoError(t, store.Begin(record))
+			}
+
+			require.NoError(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+			require.Len(t, server.updates, 1)
+			assert.Equal(t, api.TaskStatusFailed, server.updates[0].Status)
+			assert.Equal(t, agentRestartedCode, server.updates[0].ErrorCode)
+			assert.Nil(t, store.Current())
+		})
+	}
+}
+
+func TestRecoverInFlightTaskResume(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+	server := &recoveryServer{status: api.TaskStatusProcessing}
+	store := setupRecoveryTest(t, server, recoveryResume, state.TaskRecord{TaskID: "crashed-task", Source: taskSourcePoll})
+
+	require.NoError(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusProcessing, api.TaskStatusCompleted}, server.statuses())
+	assert.Nil(t, store.Current())
+}
+
+func TestRecoverInFlightTaskAPIUnavailable(t *testing.T) {
+	server := &recoveryServer{status: api.TaskStatusProcessing}
+	store := setupRecoveryTest(t, server, recoveryResume, state.TaskRecord{TaskID: "crashed-task", Source: taskSourcePoll})
+	// 接続できないAPI
+	config.GlobalConfig.API.URL = "http://127.0.0.1:1"
+	config.GlobalConfig.ErrorHandling.RetryCount = 1
+
+	// 記録を残し、次回の呼び出しで再度照合する
+	assert.Error(t, recoverInFlightTask(context.Background(), api.NewClient(), recoveryLogger()))
+	assert.NotNil(t, store.Current())
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/outbox"
+
+	"github.com/sirupsen/logrus"
+)
+
+// taskOutbox はAPIに送信できなかった書き込みを保存するアウトボックスです（runDaemon実行中のみ設定されます）
+var taskOutbox *outbox.Client
+
+// finalStatusRetryPolicy はタスクの最終ステータス送信の再試行方針です
+// APIの再起動中でも最終ステータスが失われないよう、クライアント自体の再試行に加えて全体を再試行します
+var finalStatusRetryPolicy = api.RetryPolicy{
+	MaxAttempts: 10,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    time.Minute,
+}
+
+// finalStatusTimeout は最終ステータスの送信を諦めるまでの時間です
+var finalStatusTimeout = 10 * time.Minute
+
+// reportFinalStatus は最終ステータスの送信をfinalStatusRetryPolicyで再試行します
+// 送信前にアウトボックスの未送信の書き込みを送信し、ステータスやログが完了通知より後に届かないようにします
+// デーモンの停止中（ctxのキャンセル後）でも、finalStatusTimeoutの間は送信を続けます
+// sendにはキャンセルを外したコンテキストを束縛したクライアントを渡します
+func reportFinalStatus(ctx context.Context, apiClient api.KerutaAPI, operation string, send func(api.KerutaAPI) error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalStatusTimeout)
+	defer cancel()
+	apiClient = api.WithContext(apiClient, ctx)
+
+	err := finalStatusRetryPolicy.Do(ctx, operation, func(ctx context.Context) error {
+		if taskOutbox != nil {
+			if err := taskOutbox.Flush(ctx); err != nil {
+				return err
+			}
+		}
+		return send(apiClient)
+	})
+	if err != nil && taskOutbox != nil && taskOutbox.Pending() > 0 {
+		// 未送信の書き込みの後ろに最終ステータスを保存し、接続の回復後に順番通り送信する
+		return send(apiClient)
+	}
+	return err
+}
+
+// flushOutbox はアウトボックスに未送信の書き込みがあれば送信します
+func flushOutbox(ctx context.Context, logger *logrus.Entry) {
+	if taskOutbox == nil || taskOutbox.Pending() == 0 {
+		return
+	}
+	pending := taskOutbox.Pending()
+	if err := taskOutbox.Flush(ctx); err != nil {
+		logger.WithError(err).WithField("pending", taskOutbox.Pending()).Warn("アウトボックスの再送信に失敗しました")
+		return
+	}
+	logger.WithField("count", pending).Info("📤 アウトボックスの未送信の書き込みを再送信しました")
+}
+
+// reportTaskFailure はタスクの失敗を通知します。通知に失敗した場合はログに記録します
+func reportTaskFailure(ctx context.Context, apiClient api.KerutaAPI, taskID, message, errorCode string, logger *logrus.Entry) {
+	err := reportFinalStatus(ctx, apiClient, "FailTask", func(apiClient api.KerutaAPI) error {
+		return apiClient.FailTask(taskID, message, errorCode)
+	})
+	if err != nil {
+		logger.WithError(err).Error("タスク失敗の通知に失敗しました")
+	}
+}
+
+// reportTaskSuccess はタスクの成功を通知します
+func reportTaskSuccess(ctx context.Context, apiClient api.KerutaAPI, taskID, message string) error {
+	return reportFinalStatus(ctx, apiClient, "SuccessTask", func(apiClient api.KerutaAPI) error {
+		return apiClient.SuccessTask(taskID, message)
+	})
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"keruta-agent/internal/api"
+
+	"github.com/sirupsen/logrus"
+)
+
+// taskWatchInterval は実行中のタスクのステータスをサーバーに確認する間隔です
+var taskWatchInterval = 10 * time.Second
+
+// remoteCancellationError はタスクがサーバー側でキャンセル・終了されたことを表します
+type remoteCancellationError struct {
+	status api.TaskStatus
+}
+
+func (e *remoteCancellationError) Error() string {
+	return fmt.Sprintf("タスクがサーバー側で%sになりました", e.status)
+}
+
+// watchRemoteCancellation はctxが終了するまでタスクのステータスを定期的に確認し、
+// サーバー側で終了状態になった場合はcancelを呼び出してタスクの実行を停止します
+func watchRemoteCancellation(ctx context.Context, apiClient api.KerutaAPI, taskID string, cancel context.CancelCauseFunc, logger *logrus.Entry) {
+	ticker := time.NewTicker(taskWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := checkRemoteCancellation(apiClient, taskID, logger); err != nil {
+				logger.WithError(err).Warn("🚫 タスクがサーバー側でキャンセルされたため、実行を停止します")
+				cancel(err)
+				return
+			}
+		}
+	}
+}
+
+// checkRemoteCancellation はタスクがサーバー側で終了状態になっている場合にremoteCancellationErrorを返します
+// ステータスの取得に失敗した場合は実行を継続するためnilを返します
+func checkRemoteCancellation(apiClient api.KerutaAPI, taskID string, logger *logrus.Entry) error {
+	task, err := apiClient.GetTask(taskID)
+	if err != nil {
+		logger.WithError(err).Debug("タスクのステータス確認に失敗しました")
+		return nil
+	}
+	if task.Status.IsTerminal() {
+		return &remoteCancellationError{status: task.Status}
+	}
+	return nil
+}
@@ -7,7 +7,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -19,6 +21,8 @@ type Config struct {
 	Logging       LoggingConfig       `mapstructure:"logging"`
 	Artifacts     ArtifactsConfig     `mapstructure:"artifacts"`
 	ErrorHandling ErrorHandlingConfig `mapstructure:"error_handling"`
+	Task          TaskConfig          `mapstructure:"task"`
+	Tracing       TracingConfig       `mapstructure:"tracing"`
 }
 
 // APIConfig はAPI関連の設定を表します
@@ -32,6 +36,14 @@ type APIConfig struct {
 type LoggingConfig struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
+	// BufferSize はAPIへの送信を待つログを保持する件数です
+	BufferSize int `mapstructure:"buffer_size"`
+	// OverflowPolicy はバッファが満杯のときの扱いです（drop_oldest, drop_newest, sample）
+	OverflowPolicy string `mapstructure:"overflow_policy"`
+	// RedactEnvPatterns は値をマスクする環境変数名のパターンです（カンマ区切り、例: *TOKEN*,*SECRET*）
+	RedactEnvPatterns string `mapstructure:"redact_env_patterns"`
+	// RedactPatterns はマスクする文字列の正規表現です（改行区切り）
+	RedactPatterns string `mapstructure:"redact_patterns"`
 }
 
 // ArtifactsConfig は成果物関連の設定を表します
@@ -39,6 +51,18 @@ type ArtifactsConfig struct {
 	MaxSize    int64  `mapstructure:"max_size"`
 	Directory  string `mapstructure:"directory"`
 	Extensions string `mapstructure:"extensions"`
+	// Include は収集するファイルの.gitignore形式のパターンです（カンマまたは改行区切り）
+	Include string `mapstructure:"include"`
+	// Exclude は除外するファイル・ディレクトリの.gitignore形式のパターンです（カンマまたは改行区切り）
+	Exclude string `mapstructure:"exclude"`
+	// ChunkSize は分割アップロードの1チャンクのサイズ（バイト）です。これより大きいファイルは分割してアップロードします
+	ChunkSize int64 `mapstructure:"chunk_size"`
+	// Mode は成果物のアップロード方法です（files: ファイルごと、bundle: 1つのアーカイブにまとめる、auto: 件数に応じて切り替える）
+	Mode string `mapstructure:"mode"`
+	// BundleFormat はまとめる際のアーカイブ形式です（tar.gz または zip）
+	BundleFormat string `mapstructure:"bundle_format"`
+	// BundleThreshold はautoモードでアーカイブにまとめる成果物の件数です（この件数を超えるとまとめます）
+	BundleThreshold int `mapstructure:"bundle_threshold"`
 }
 
 // ErrorHandlingConfig はエラーハンドリング関連の設定を表します
@@ -47,6 +71,67 @@ type ErrorHandlingConfig struct {
 	RetryCount int  `mapstructure:"retry_count"`
 }
 
+// TaskConfig はタスク実行関連の設定を表します
+type TaskConfig struct {
+	// Timeout はタスクの最大実行時間です（0の場合は無制限）
+	Timeout time.Duration `mapstructure:"timeout"`
+	// KillGracePeriod はSIGTERM送信後、SIGKILLを送信するまでの猶予時間です
+	KillGracePeriod time.Duration `mapstructure:"kill_grace_period"`
+	// RecoveryPolicy はエージェントの再起動時に実行中だったタスクの扱いです（resume, requeue, fail）
+	RecoveryPolicy string `mapstructure:"recovery_policy"`
+	// MaxRestarts は1つのタスクの実行中にエージェントが再起動してもタスクを再開する回数です
+	MaxRestarts int `mapstructure:"max_restarts"`
+}
+
+// TracingConfig はOpenTelemetryのトレース設定を表します
+type TracingConfig struct {
+	// Exporter はトレースの出力先です（none, otlp, stdout, file）
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint はOTLP/HTTPの送信先（host:port）です。未設定の場合はOTEL_EXPORTER_OTLP_*環境変数に従います
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure はOTLPをTLSなしで送信します
+	Insecure bool `mapstructure:"insecure"`
+	// File はfileエクスポーターの出力先です（未設定の場合は状態ディレクトリのtraces.jsonl）
+	File string `mapstructure:"file"`
+	// SampleRatio はトレースを記録する割合（0〜1）です。0の場合は記録せず、未設定の場合はデフォルト値を使います
+	SampleRatio *float64 `mapstructure:"sample_ratio"`
+}
+
+const (
+	// DefaultTaskTimeout はタスクの最大実行時間のデフォルト値です
+	DefaultTaskTimeout = 2 * time.Hour
+	// DefaultKillGracePeriod はSIGKILLを送信するまでの猶予時間のデフォルト値です
+	DefaultKillGracePeriod = 10 * time.Second
+	// DefaultTaskRecoveryPolicy は再起動時に実行中だったタスクの扱いのデフォルト値です
+	DefaultTaskRecoveryPolicy = "resume"
+	// DefaultTaskMaxRestarts はタスクを再開する回数のデフォルト値です
+	DefaultTaskMaxRestarts = 2
+	// DefaultLogBufferSize はAPIへの送信を待つログを保持する件数のデフォルト値です
+	DefaultLogBufferSize = 1000
+	// DefaultLogOverflowPolicy はログのバッファが満杯のときの扱いのデフォルト値です
+	DefaultLogOverflowPolicy = "drop_oldest"
+	// DefaultRetryCount はAPI呼び出しの最大試行回数のデフォルト値です
+	DefaultRetryCount = 3
+	// DefaultArtifactsDirectory は成果物ディレクトリのデフォルト値です
+	DefaultArtifactsDirectory = "/.keruta/doc"
+	// DefaultArtifactsMaxSize は成果物の最大ファイルサイズ（バイト）のデフォルト値です
+	DefaultArtifactsMaxSize = 100 * 1024 * 1024
+	// DefaultArtifactsChunkSize は分割アップロードの1チャンクのサイズ（バイト）のデフォルト値です
+	DefaultArtifactsChunkSize = 8 * 1024 * 1024
+	// DefaultArtifactsMode は成果物のアップロード方法のデフォルト値です
+	DefaultArtifactsMode = "auto"
+	// DefaultArtifactsBundleFormat は成果物をまとめるアーカイブ形式のデフォルト値です
+	DefaultArtifactsBundleFormat = "tar.gz"
+	// DefaultArtifactsBundleThreshold はautoモードで成果物をまとめる件数のデフォルト値です
+	DefaultArtifactsBundleThreshold = 50
+	// DefaultTracingExporter はトレースの出力先のデフォルト値です（出力しない）
+	DefaultTracingExporter = "none"
+	// DefaultTracingSampleRatio はトレースを記録する割合のデフォルト値です
+	DefaultTracingSampleRatio = 1.0
+	// DefaultTracingFileName はfileエクスポーターの状態ディレクトリ内の出力先です
+	DefaultTracingFileName = "traces.jsonl"
+)
+
 var (
 	// GlobalConfig はグローバル設定インスタンスです
 	GlobalConfig *Config
@@ -78,10 +163,22 @@ func setDefaults() {
 	viper.SetDefault("api.timeout", "30s")
 	viper.SetDefault("logging.level", "INFO")
 	viper.SetDefault("logging.format", "json")
-	viper.SetDefault("artifacts.max_size", 100*1024*1024) // 100MB
-	viper.SetDefault("artifacts.directory", "/.keruta/doc")
+	viper.SetDefault("logging.buffer_size", DefaultLogBufferSize)
+	viper.SetDefault("logging.overflow_policy", DefaultLogOverflowPolicy)
+	viper.SetDefault("artifacts.max_size", DefaultArtifactsMaxSize) // 100MB
+	viper.SetDefault("artifacts.directory", DefaultArtifactsDirectory)
+	viper.SetDefault("artifacts.chunk_size", DefaultArtifactsChunkSize) // 8MB
+	viper.SetDefault("artifacts.mode", DefaultArtifactsMode)
+	viper.SetDefault("artifacts.bundle_format", DefaultArtifactsBundleFormat)
+	viper.SetDefault("artifacts.bundle_threshold", DefaultArtifactsBundleThreshold)
 	viper.SetDefault("error_handling.auto_fix", true)
-	viper.SetDefault("error_handling.retry_count", 3)
+	viper.SetDefault("error_handling.retry_count", DefaultRetryCount)
+	viper.SetDefault("task.timeout", DefaultTaskTimeout.String())
+	viper.SetDefault("task.kill_grace_period", DefaultKillGracePeriod.String())
+	viper.SetDefault("task.recovery_policy", DefaultTaskRecoveryPolicy)
+	viper.SetDefault("task.max_restarts", DefaultTaskMaxRestarts)
+	viper.SetDefault("tracing.exporter", DefaultTracingExporter)
+	viper.SetDefault("tracing.sample_ratio", DefaultTracingSampleRatio)
 }
 
 // loadFromEnv は環境変数から設定を読み込みます
@@ -101,16 +198,55 @@ func loadFromEnv() {
 	if level := os.Getenv("KERUTA_LOG_LEVEL"); level != "" {
 		viper.Set("logging.level", level)
 	}
+	if bufferSize := os.Getenv("KERUTA_LOG_BUFFER_SIZE"); bufferSize != "" {
+		if size, err := strconv.Atoi(bufferSize); err == nil {
+			viper.Set("logging.buffer_size", size)
+		}
+	}
+	if policy := os.Getenv("KERUTA_LOG_OVERFLOW_POLICY"); policy != "" {
+		viper.Set("logging.overflow_policy", policy)
+	}
+	if patterns := os.Getenv("KERUTA_REDACT_ENV_PATTERNS"); patterns != "" {
+		viper.Set("logging.redact_env_patterns", patterns)
+	}
+	if patterns := os.Getenv("KERUTA_REDACT_PATTERNS"); patterns != "" {
+		viper.Set("logging.redact_patterns", patterns)
+	}
 
 	// 成果物設定
 	if dir := os.Getenv("KERUTA_ARTIFACTS_DIR"); dir != "" {
 		viper.Set("artifacts.directory", dir)
 	}
+	if extensions := os.Getenv("KERUTA_ARTIFACTS_EXTENSIONS"); extensions != "" {
+		viper.Set("artifacts.extensions", extensions)
+	}
+	if include := os.Getenv("KERUTA_ARTIFACTS_INCLUDE"); include != "" {
+		viper.Set("artifacts.include", include)
+	}
+	if exclude := os.Getenv("KERUTA_ARTIFACTS_EXCLUDE"); exclude != "" {
+		viper.Set("artifacts.exclude", exclude)
+	}
 	if maxSize := os.Getenv("KERUTA_MAX_FILE_SIZE"); maxSize != "" {
 		if size, err := strconv.ParseInt(maxSize, 10, 64); err == nil {
 			viper.Set("artifacts.max_size", size*1024*1024) // MB to bytes
 		}
 	}
+	if chunkSize := os.Getenv("KERUTA_ARTIFACTS_CHUNK_SIZE"); chunkSize != "" {
+		if size, err := strconv.ParseInt(chunkSize, 10, 64); err == nil {
+			viper.Set("artifacts.chunk_size", size*1024*1024) // MB to bytes
+		}
+	}
+	if mode := os.Getenv("KERUTA_ARTIFACTS_MODE"); mode != "" {
+		viper.Set("artifacts.mode", mode)
+	}
+	if format := os.Getenv("KERUTA_ARTIFACTS_BUNDLE_FORMAT"); format != "" {
+		viper.Set("artifacts.bundle_format", format)
+	}
+	if threshold := os.Getenv("KERUTA_ARTIFACTS_BUNDLE_THRESHOLD"); threshold != "" {
+		if count, err := strconv.Atoi(threshold); err == nil {
+			viper.Set("artifacts.bundle_threshold", count)
+		}
+	}
 
 	// エラーハンドリング設定
 	if autoFix := os.Getenv("KERUTA_AUTO_FIX_ENABLED"); autoFix != "" {
@@ -123,6 +259,56 @@ func loadFromEnv() {
 			viper.Set("error_handling.retry_count", count)
 		}
 	}
+
+	// タスク実行設定
+	if timeout := os.Getenv("KERUTA_TASK_TIMEOUT"); timeout != "" {
+		if duration, err := ParseDurationOrSeconds(timeout); err == nil {
+			viper.Set("task.timeout", duration.String())
+		}
+	}
+	if grace := os.Getenv("KERUTA_TASK_KILL_GRACE_PERIOD"); grace != "" {
+		if duration, err := ParseDurationOrSeconds(grace); err == nil {
+			viper.Set("task.kill_grace_period", duration.String())
+		}
+	}
+	if policy := os.Getenv("KERUTA_TASK_RECOVERY_POLICY"); policy != "" {
+		viper.Set("task.recovery_policy", policy)
+	}
+	if restarts := os.Getenv("KERUTA_TASK_MAX_RESTARTS"); restarts != "" {
+		if value, err := strconv.Atoi(restarts); err == nil {
+			viper.Set("task.max_restarts", value)
+		}
+	}
+
+	// トレース設定
+	if exporter := os.Getenv("KERUTA_TRACING_EXPORTER"); exporter != "" {
+		viper.Set("tracing.exporter", exporter)
+	}
+	if endpoint := os.Getenv("KERUTA_TRACING_ENDPOINT"); endpoint != "" {
+		viper.Set("tracing.endpoint", endpoint)
+	}
+	if insecure := os.Getenv("KERUTA_TRACING_INSECURE"); insecure != "" {
+		if enabled, err := strconv.ParseBool(insecure); err == nil {
+			viper.Set("tracing.insecure", enabled)
+		}
+	}
+	if file := os.Getenv("KERUTA_TRACING_FILE"); file != "" {
+		viper.Set("tracing.file", file)
+	}
+	if ratio := os.Getenv("KERUTA_TRACING_SAMPLE_RATIO"); ratio != "" {
+		if value, err := strconv.ParseFloat(ratio, 64); err == nil {
+			viper.Set("tracing.sample_ratio", value)
+		}
+	}
+}
+
+// ParseDurationOrSeconds は"30m"のような時間表記、または秒数を表す整数を解析します
+func ParseDurationOrSeconds(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
 }
 
 // loadFromFile は設定ファイルから設定を読み込みます
@@ -151,23 +337,22 @@ func validate() error {
 		return fmt.Errorf("KERUTA_API_URL が設定されていません")
 	}
 	
-	// デーモンモード以外では KERUTA_TASK_ID が必須
 	// デーモンモードではセッションIDまたはワークスペースIDが必要
 	// デーモンモードの判定: コマンドライン引数から判定
+	// --task-id フラグが指定されている場合はコマンド実行時に環境変数へ設定される
+	// KERUTA_TASK_ID が必要なコマンドでの検証は、コマンドの実行前にルートコマンドで行う
 	isDaemonMode := false
+	hasTaskIDFlag := false
 	for _, arg := range os.Args {
 		if arg == "daemon" {
 			isDaemonMode = true
-			break
+		}
+		if arg == "--task-id" || strings.HasPrefix(arg, "--task-id=") {
+			hasTaskIDFlag = true
 		}
 	}
 	
-	if os.Getenv("KERUTA_TASK_ID") == "" {
-		if !isDaemonMode {
-			// 通常モードではTASK_IDが必須
-			return fmt.Errorf("KERUTA_TASK_ID が設定されていません")
-		}
-		
+	if os.Getenv("KERUTA_TASK_ID") == "" && !hasTaskIDFlag && isDaemonMode {
 		// デーモンモードの場合は、セッションIDまたはワークスペースIDをチェック
 		sessionID := os.Getenv("KERUTA_SESSION_ID")
 		workspaceID := os.Getenv("KERUTA_WORKSPACE_ID")
@@ -223,9 +408,167 @@ func GetTimeout() time.Duration {
 
 // GetAPIToken はAPIトークンを取得します
 func GetAPIToken() string {
+	if GlobalConfig == nil {
+		return ""
+	}
 	return GlobalConfig.API.Token
 }
 
+// GetLogBufferSize はAPIへの送信を待つログを保持する件数を取得します
+func GetLogBufferSize() int {
+	if GlobalConfig == nil || GlobalConfig.Logging.BufferSize <= 0 {
+		return DefaultLogBufferSize
+	}
+	return GlobalConfig.Logging.BufferSize
+}
+
+// GetLogOverflowPolicy はログのバッファが満杯のときの扱いを取得します
+func GetLogOverflowPolicy() string {
+	if GlobalConfig == nil || GlobalConfig.Logging.OverflowPolicy == "" {
+		return DefaultLogOverflowPolicy
+	}
+	return GlobalConfig.Logging.OverflowPolicy
+}
+
+// GetRedactEnvPatterns は値をマスクする環境変数名のパターンを取得します（未設定の場合はnil）
+func GetRedactEnvPatterns() []string {
+	if GlobalConfig == nil {
+		return nil
+	}
+	return splitList(GlobalConfig.Logging.RedactEnvPatterns, ",")
+}
+
+// GetRedactPatterns はマスクする文字列の正規表現を取得します（未設定の場合はnil）
+func GetRedactPatterns() []string {
+	if GlobalConfig == nil {
+		return nil
+	}
+	return splitList(GlobalConfig.Logging.RedactPatterns, "\n")
+}
+
+// splitList は区切り文字で分割し、空の要素を除いて返します
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetArtifactsDirectory は成果物ディレクトリを取得します
+func GetArtifactsDirectory() string {
+	if GlobalConfig == nil || GlobalConfig.Artifacts.Directory == "" {
+		return DefaultArtifactsDirectory
+	}
+	return GlobalConfig.Artifacts.Directory
+}
+
+// GetArtifactsMaxSize は成果物の最大ファイルサイズ（バイト）を取得します
+func GetArtifactsMaxSize() int64 {
+	if GlobalConfig == nil || GlobalConfig.Artifacts.MaxSize <= 0 {
+		return DefaultArtifactsMaxSize
+	}
+	return GlobalConfig.Artifacts.MaxSize
+}
+
+// GetArtifactsChunkSize は分割アップロードの1チャンクのサイズ（バイト）を取得します
+func GetArtifactsChunkSize() int64 {
+	if GlobalConfig == nil || GlobalConfig.Artifacts.ChunkSize <= 0 {
+		return DefaultArtifactsChunkSize
+	}
+	return GlobalConfig.Artifacts.ChunkSize
+}
+
+// GetArtifactsMode は成果物のアップロード方法を取得します
+func GetArtifactsMode() string {
+	if GlobalConfig == nil || GlobalConfig.Artifacts.Mode == "" {
+		return DefaultArtifactsMode
+	}
+	return GlobalConfig.Artifacts.Mode
+}
+
+// GetArtifactsBundleFormat は成果物をまとめるアーカイブ形式を取得します
+func GetArtifactsBundleFormat() string {
+	if GlobalConfig == nil || GlobalConfig.Artifacts.BundleFormat == "" {
+		return DefaultArtifactsBundleFormat
+	}
+	return GlobalConfig.Artifacts.BundleFormat
+}
+
+// GetArtifactsBundleThreshold はautoモードで成果物をまとめる件数を取得します
+func GetArtifactsBundleThreshold() int {
+	if GlobalConfig == nil || GlobalConfig.Artifacts.BundleThreshold <= 0 {
+		return DefaultArtifactsBundleThreshold
+	}
+	return GlobalConfig.Artifacts.BundleThreshold
+}
+
+// GetArtifactsExtensions は収集する成果物の拡張子を取得します（未設定の場合はnil）
+func GetArtifactsExtensions() []string {
+	if GlobalConfig == nil {
+		return nil
+	}
+	return splitList(strings.ReplaceAll(GlobalConfig.Artifacts.Extensions, "\n", ","), ",")
+}
+
+// GetArtifactsInclude は収集する成果物のパターンを取得します（未設定の場合はnil）
+func GetArtifactsInclude() []string {
+	if GlobalConfig == nil {
+		return nil
+	}
+	return splitList(strings.ReplaceAll(GlobalConfig.Artifacts.Include, "\n", ","), ",")
+}
+
+// GetArtifactsExclude は成果物から除外するパターンを取得します（未設定の場合はnil）
+func GetArtifactsExclude() []string {
+	if GlobalConfig == nil {
+		return nil
+	}
+	return splitList(strings.ReplaceAll(GlobalConfig.Artifacts.Exclude, "\n", ","), ",")
+}
+
+// GetRetryCount はAPI呼び出しの最大試行回数を取得します
+func GetRetryCount() int {
+	if GlobalConfig == nil {
+		return DefaultRetryCount
+	}
+	return GlobalConfig.ErrorHandling.RetryCount
+}
+
+// GetTaskTimeout はタスクの最大実行時間を取得します（0の場合は無制限）
+func GetTaskTimeout() time.Duration {
+	if GlobalConfig == nil {
+		return DefaultTaskTimeout
+	}
+	return GlobalConfig.Task.Timeout
+}
+
+// GetKillGracePeriod はSIGTERM送信後、SIGKILLを送信するまでの猶予時間を取得します
+func GetKillGracePeriod() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Task.KillGracePeriod <= 0 {
+		return DefaultKillGracePeriod
+	}
+	return GlobalConfig.Task.KillGracePeriod
+}
+
+// GetTaskRecoveryPolicy はエージェントの再起動時に実行中だったタスクの扱いを取得します
+func GetTaskRecoveryPolicy() string {
+	if GlobalConfig == nil || GlobalConfig.Task.RecoveryPolicy == "" {
+		return DefaultTaskRecoveryPolicy
+	}
+	return strings.ToLower(strings.TrimSpace(GlobalConfig.Task.RecoveryPolicy))
+}
+
+// GetTaskMaxRestarts はタスクの実行中にエージェントが再起動してもタスクを再開する回数を取得します
+func GetTaskMaxRestarts() int {
+	if GlobalConfig == nil || GlobalConfig.Task.MaxRestarts < 0 {
+		return DefaultTaskMaxRestarts
+	}
+	return GlobalConfig.Task.MaxRestarts
+}
+
 // GetSessionID はセッションIDを取得します
 func GetSessionID() string {
 	if sessionID := os.Getenv("KERUTA_SESSION_ID"); sessionID != "" {
@@ -308,6 +651,14 @@ func GetUseHTTPInput() bool {
 	return os.Getenv("KERUTA_USE_HTTP_INPUT") == "true"
 }
 
+// GetDaemonHost はデーモンHTTPサーバーの待ち受けホストを取得します
+func GetDaemonHost() string {
+	if host := os.Getenv("KERUTA_DAEMON_HOST"); host != "" {
+		return host
+	}
+	return "localhost" // デフォルト値
+}
+
 // GetDaemonPort はデーモンHTTPポートを取得します
 func GetDaemonPort() string {
 	if port := os.Getenv("KERUTA_DAEMON_PORT"); port != "" {
@@ -315,3 +666,63 @@ func GetDaemonPort() string {
 	}
 	return "8080" // デフォルト値
 }
+
+// GetDaemonCORSOrigins はデーモンHTTPサーバーへのブラウザからのリクエストを許可するオリジンを取得します（未設定の場合はnil）
+func GetDaemonCORSOrigins() []string {
+	return splitList(os.Getenv("KERUTA_DAEMON_CORS_ORIGINS"), ",")
+}
+
+// GetStateDir はエージェントの状態（アウトボックスなど）を保存するディレクトリを取得します
+func GetStateDir() string {
+	if dir := os.Getenv("KERUTA_STATE_DIR"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".keruta", "state")
+	}
+	return filepath.Join(os.TempDir(), "keruta-state") // デフォルト値
+}
+
+// GetTracingExporter はトレースの出力先を取得します
+func GetTracingExporter() string {
+	if GlobalConfig == nil || GlobalConfig.Tracing.Exporter == "" {
+		return DefaultTracingExporter
+	}
+	return strings.ToLower(strings.TrimSpace(GlobalConfig.Tracing.Exporter))
+}
+
+// GetTracingEndpoint はOTLPの送信先を取得します（未設定の場合は空文字）
+func GetTracingEndpoint() string {
+	if GlobalConfig == nil {
+		return ""
+	}
+	return GlobalConfig.Tracing.Endpoint
+}
+
+// GetTracingInsecure はOTLPをTLSなしで送信するかどうかを取得します
+func GetTracingInsecure() bool {
+	return GlobalConfig != nil && GlobalConfig.Tracing.Insecure
+}
+
+// GetTracingFile はfileエクスポーターの出力先を取得します
+func GetTracingFile() string {
+	if GlobalConfig == nil || GlobalConfig.Tracing.File == "" {
+		return filepath.Join(GetStateDir(), DefaultTracingFileName)
+	}
+	return GlobalConfig.Tracing.File
+}
+
+// GetTracingSampleRatio はトレースを記録する割合を取得します（0の場合はトレースを記録しません）
+func GetTracingSampleRatio() float64 {
+	if GlobalConfig == nil || GlobalConfig.Tracing.SampleRatio == nil {
+		return DefaultTracingSampleRatio
+	}
+	ratio := *GlobalConfig.Tracing.SampleRatio
+	if ratio < 0 {
+		return 0
+	}
+	if ratio > 1 {
+		return 1
+	}
+	return ratio
+}
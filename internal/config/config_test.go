@@ -3,6 +3,7 @@ package config
 import (
 	"github.com/spf13/viper"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -141,10 +142,10 @@ func TestValidateMissingTaskID(t *testing.T) {
 	os.Args = []string{"keruta-agent", "execute"}
 	defer func() { os.Args = originalArgs }()
 
+	// タスクIDが必要かどうかはコマンドごとに実行前に検証するため、設定の検証ではエラーにしない
 	err := validate()
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "KERUTA_TASK_ID が設定されていません")
+	assert.NoError(t, err)
 }
 
 func TestGetTaskID(t *testing.T) {
@@ -378,6 +379,28 @@ func TestGetDaemonPortCustom(t *testing.T) {
 	assert.Equal(t, "9090", port)
 }
 
+func TestGetDaemonHost(t *testing.T) {
+	// デフォルト値のテスト
+	os.Unsetenv("KERUTA_DAEMON_HOST")
+	assert.Equal(t, "localhost", GetDaemonHost())
+
+	// カスタム値のテスト
+	os.Setenv("KERUTA_DAEMON_HOST", "0.0.0.0")
+	defer os.Unsetenv("KERUTA_DAEMON_HOST")
+	assert.Equal(t, "0.0.0.0", GetDaemonHost())
+}
+
+func TestGetStateDir(t *testing.T) {
+	// デフォルト値のテスト
+	os.Unsetenv("KERUTA_STATE_DIR")
+	t.Setenv("HOME", "/home/keruta")
+	assert.Equal(t, filepath.Join("/home/keruta", ".keruta", "state"), GetStateDir())
+
+	// カスタム値のテスト
+	t.Setenv("KERUTA_STATE_DIR", "/var/lib/keruta")
+	assert.Equal(t, "/var/lib/keruta", GetStateDir())
+}
+
 func TestGetAPIToken(t *testing.T) {
 	// GlobalConfigを設定
 	viper.Reset()
@@ -568,3 +591,121 @@ func TestConfigStructInitialization(t *testing.T) {
 
 	GlobalConfig = nil
 }
+
+func TestTaskTimeoutConfig(t *testing.T) {
+	viper.Reset()
+	setDefaults()
+
+	os.Setenv("KERUTA_TASK_TIMEOUT", "1800")
+	os.Setenv("KERUTA_TASK_KILL_GRACE_PERIOD", "5s")
+	defer func() {
+		os.Unsetenv("KERUTA_TASK_TIMEOUT")
+		os.Unsetenv("KERUTA_TASK_KILL_GRACE_PERIOD")
+		GlobalConfig = nil
+	}()
+
+	loadFromEnv()
+
+	var config Config
+	require.NoError(t, viper.Unmarshal(&config))
+	GlobalConfig = &config
+
+	assert.Equal(t, 30*time.Minute, GetTaskTimeout())
+	assert.Equal(t, 5*time.Second, GetKillGracePeriod())
+
+	// 設定が初期化されていない場合はデフォルト値
+	GlobalConfig = nil
+	assert.Equal(t, DefaultTaskTimeout, GetTaskTimeout())
+	assert.Equal(t, DefaultKillGracePeriod, GetKillGracePeriod())
+}
+
+func TestParseDurationOrSeconds(t *testing.T) {
+	duration, err := ParseDurationOrSeconds("90")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, duration)
+
+	duration, err = ParseDurationOrSeconds("1h30m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, duration)
+
+	_, err = ParseDurationOrSeconds("soon")
+	assert.Error(t, err)
+}
+
+func TestTracingConfig(t *testing.T) {
+	viper.Reset()
+	setDefaults()
+
+	t.Setenv("KERUTA_TRACING_EXPORTER", " OTLP ")
+	t.Setenv("KERUTA_TRACING_ENDPOINT", "http://otel-collector:4318")
+	t.Setenv("KERUTA_TRACING_INSECURE", "true")
+	t.Setenv("KERUTA_TRACING_SAMPLE_RATIO", "0.25")
+	defer func() {
+		viper.Reset()
+		GlobalConfig = nil
+	}()
+
+	loadFromEnv()
+
+	var config Config
+	require.NoError(t, viper.Unmarshal(&config))
+	GlobalConfig = &config
+
+	assert.Equal(t, "otlp", GetTracingExporter())
+	assert.Equal(t, "http://otel-collector:4318", GetTracingEndpoint())
+	assert.True(t, GetTracingInsecure())
+	assert.Equal(t, 0.25, GetTracingSampleRatio())
+
+	// 範囲外の割合は0〜1に丸める
+	ratio := 2.0
+	GlobalConfig.Tracing.SampleRatio = &ratio
+	assert.Equal(t, 1.0, GetTracingSampleRatio())
+	ratio = -1
+	assert.Zero(t, GetTracingSampleRatio())
+
+	// 0を指定した場合はトレースを記録しない
+	t.Setenv("KERUTA_TRACING_SAMPLE_RATIO", "0")
+	loadFromEnv()
+	config = Config{}
+	require.NoError(t, viper.Unmarshal(&config))
+	assert.Zero(t, GetTracingSampleRatio())
+
+	// 未設定の場合はデフォルト値
+	GlobalConfig.Tracing.SampleRatio = nil
+	assert.Equal(t, DefaultTracingSampleRatio, GetTracingSampleRatio())
+
+	// 出力先ファイルの既定値は状態ディレクトリ内
+	t.Setenv("KERUTA_STATE_DIR", "/var/lib/keruta")
+	assert.Equal(t, filepath.Join("/var/lib/keruta", DefaultTracingFileName), GetTracingFile())
+
+	// 設定が初期化されていない場合はトレースを出力しない
+	GlobalConfig = nil
+	assert.Equal(t, DefaultTracingExporter, GetTracingExporter())
+	assert.False(t, GetTracingInsecure())
+}
+
+func TestTaskRecoveryConfig(t *testing.T) {
+	viper.Reset()
+	setDefaults()
+
+	t.Setenv("KERUTA_TASK_RECOVERY_POLICY", " Requeue ")
+	t.Setenv("KERUTA_TASK_MAX_RESTARTS", "0")
+	defer func() {
+		viper.Reset()
+		GlobalConfig = nil
+	}()
+
+	loadFromEnv()
+
+	var config Config
+	require.NoError(t, viper.Unmarshal(&config))
+	GlobalConfig = &config
+
+	assert.Equal(t, "requeue", GetTaskRecoveryPolicy())
+	assert.Equal(t, 0, GetTaskMaxRestarts())
+
+	// 設定が初期化されていない場合はデフォルト値
+	GlobalConfig = nil
+	assert.Equal(t, DefaultTaskRecoveryPolicy, GetTaskRecoveryPolicy())
+	assert.Equal(t, DefaultTaskMaxRestarts, GetTaskMaxRestarts())
+}
@@ -0,0 +1,466 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/logger"
+	"keruta-agent/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// taskQueueSize は/executeで受け付けたタスクを保持できる最大数です
+const taskQueueSize = 16
+
+// EnvPrefix は/executeのリクエストで指定できる環境変数の名前の接頭辞です
+// PATHやLD_PRELOADなど、タスク以外の動作を変える環境変数は受け付けません
+const EnvPrefix = "KERUTA_PARAM_"
+
+var (
+	// version はビルド時に設定されるバージョンです
+	version = "dev"
+	// buildTime はビルド時に設定されるビルド日時です
+	buildTime = "unknown"
+)
+
+// SetBuildInfo はステータスAPIで返すビルド情報を設定します
+func SetBuildInfo(v, bt string) {
+	if v != "" {
+		version = v
+	}
+	if bt != "" {
+		buildTime = bt
+	}
+}
+
+// Version はビルド時に設定されたバージョンを返します
+func Version() string {
+	return version
+}
+
+// TaskRequest は/executeで受け付けるタスク実行リクエストを表します
+type TaskRequest struct {
+	TaskID      string            `json:"taskId"`
+	Script      string            `json:"script"`
+	Language    string            `json:"language,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// TaskInfo は実行中のタスクの情報を表します
+type TaskInfo struct {
+	TaskID    string    `json:"taskId"`
+	SessionID string    `json:"sessionId,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Source    string    `json:"source"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Daemon はデーモンの制御用HTTPサーバーです
+// タスク実行はポーリングループ側で行い、サーバーはキューへの投入と状態の参照のみを担当します
+type Daemon struct {
+	host      string
+	port      string
+	server    *http.Server
+	startTime time.Time
+	tasks     chan *TaskRequest
+
+	mu             sync.RWMutex
+	currentTask    *TaskInfo
+	tasksExecuted  int64
+	tasksSucceeded int64
+	tasksFailed    int64
+}
+
+// NewDaemon は設定のポートで待ち受ける新しいDaemonを作成します
+func NewDaemon() *Daemon {
+	return NewDaemonWithAddress(config.GetDaemonHost(), config.GetDaemonPort())
+}
+
+// NewDaemonWithAddress は指定したホスト・ポートで待ち受ける新しいDaemonを作成します
+func NewDaemonWithAddress(host, port string) *Daemon {
+	return &Daemon{
+		host:      host,
+		port:      port,
+		startTime: time.Now(),
+		tasks:     make(chan *TaskRequest, taskQueueSize),
+	}
+}
+
+// Start はHTTPサーバーを開始し、ctxがキャンセルされるとグレースフルシャットダウンします
+func (d *Daemon) Start(ctx context.Context) error {
+	d.server = &http.Server{
+		Addr:              net.JoinHostPort(d.host, d.port),
+		Handler:           d.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", d.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	logger.WithComponent("daemon").WithField("addr", listener.Addr().String()).Info("🌐 制御用HTTPサーバーを開始しました")
+
+	// バックグラウンドでサーバーを開始
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- d.server.Serve(listener)
+	}()
+
+	// コンテキストがキャンセルされるか、サーバーが停止するまで待機
+	select {
+	case <-ctx.Done():
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+
+	// グレースフルシャットダウン
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger.WithComponent("daemon").Info("制御用HTTPサーバーを停止しています...")
+	return d.server.Shutdown(shutdownCtx)
+}
+
+// Tasks は/executeで受け付けたタスクのキューを返します
+func (d *Daemon) Tasks() <-chan *TaskRequest {
+	if d == nil {
+		return nil
+	}
+	return d.tasks
+}
+
+// SetCurrentTask は実行中のタスクを設定します
+func (d *Daemon) SetCurrentTask(info *TaskInfo) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.currentTask = info
+}
+
+// FinishCurrentTask は実行中のタスクをクリアし、実行結果を集計します
+func (d *Daemon) FinishCurrentTask(success bool) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.currentTask = nil
+	d.tasksExecuted++
+	if success {
+		d.tasksSucceeded++
+	} else {
+		d.tasksFailed++
+	}
+}
+
+// CurrentTask は実行中のタスクを返します。実行中のタスクがない場合はnilを返します
+func (d *Daemon) CurrentTask() *TaskInfo {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.currentTask == nil {
+		return nil
+	}
+	info := *d.currentTask
+	return &info
+}
+
+// handler は制御用HTTP APIのハンドラーを作成します
+func (d *Daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// ルートハンドラーを設定
+	mux.HandleFunc("/health", d.healthCheckHandler)
+	mux.HandleFunc("/execute", d.taskExecutionHandler)
+	mux.HandleFunc("/metrics", d.metricsHandler)
+	mux.HandleFunc("/config", d.configHandler)
+	mux.HandleFunc("/status", d.statusHandler)
+
+	// ミドルウェアを適用
+	return d.loggingMiddleware(d.corsMiddleware(mux))
+}
+
+func (d *Daemon) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"status":    "ok",
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	d.sendJSONResponse(w, http.StatusOK, response)
+}
+
+func (d *Daemon) taskExecutionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		d.sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// 任意のスクリプトを実行できるため、エージェントのAPIトークンを持つクライアントのみ受け付ける
+	token := config.GetAPIToken()
+	if token == "" {
+		d.sendErrorResponse(w, http.StatusForbidden, "APIトークンが設定されていないため、/executeは無効です")
+		return
+	}
+	if !validBearerToken(r.Header.Get("Authorization"), token) {
+		d.sendErrorResponse(w, http.StatusUnauthorized, "認証に失敗しました")
+		return
+	}
+
+	// フォームの送信など、ブラウザが事前確認なしに送信できるリクエストは受け付けない
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		d.sendErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Typeはapplication/jsonを指定してください")
+		return
+	}
+
+	var request TaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		d.sendErrorResponse(w, http.StatusBadRequest, "リクエストの解析に失敗しました: "+err.Error())
+		return
+	}
+
+	if request.TaskID == "" {
+		d.sendErrorResponse(w, http.StatusBadRequest, "taskIdが指定されていません")
+		return
+	}
+
+	if request.Script == "" {
+		d.sendErrorResponse(w, http.StatusBadRequest, "scriptが指定されていません")
+		return
+	}
+
+	for key := range request.Environment {
+		if !validEnvName(key) {
+			d.sendErrorResponse(w, http.StatusBadRequest, "環境変数 "+key+" は指定できません（"+EnvPrefix+"で始まる名前のみ指定できます）")
+			return
+		}
+	}
+
+	// ポーリングループと同じ実行キューに投入（順次実行のため、ここでは実行しない）
+	select {
+	case d.tasks <- &request:
+	default:
+		d.sendErrorResponse(w, http.StatusServiceUnavailable, "タスクキューが満杯です")
+		return
+	}
+
+	logger.WithComponent("daemon").WithField("task_id", request.TaskID).Info("📥 HTTP経由でタスクを受け付けました")
+
+	response := map[string]interface{}{
+		"status": "accepted",
+		"taskId": request.TaskID,
+	}
+
+	d.sendJSONResponse(w, http.StatusOK, response)
+}
+
+// metricsHandler はエージェントのメトリクスをPrometheusのテキスト形式で返します
+func (d *Daemon) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	metrics.Handler(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "keruta",
+			Subsystem: "daemon",
+			Name:      "uptime_seconds",
+			Help:      "デーモンの稼働時間",
+		}, func() float64 {
+			return time.Since(d.startTime).Seconds()
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "keruta",
+			Subsystem: "daemon",
+			Name:      "queued_tasks",
+			Help:      "/executeで受け付けて実行を待っているタスクの数",
+		}, func() float64 {
+			return float64(len(d.tasks))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "keruta",
+			Subsystem: "daemon",
+			Name:      "task_running",
+			Help:      "タスクを実行中の場合は1",
+		}, func() float64 {
+			if d.CurrentTask() != nil {
+				return 1
+			}
+			return 0
+		}),
+	).ServeHTTP(w, r)
+}
+
+func (d *Daemon) configHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := config.GlobalConfig
+	if cfg == nil {
+		d.sendErrorResponse(w, http.StatusInternalServerError, "設定が初期化されていません")
+		return
+	}
+
+	response := map[string]interface{}{
+		"api_url":                    cfg.API.URL,
+		"api_token":                  "***", // セキュリティのためマスク
+		"api_timeout":                cfg.API.Timeout.String(),
+		"log_level":                  cfg.Logging.Level,
+		"log_format":                 cfg.Logging.Format,
+		"artifacts_max_size":         cfg.Artifacts.MaxSize,
+		"artifacts_directory":        cfg.Artifacts.Directory,
+		"error_handling_auto_fix":    cfg.ErrorHandling.AutoFix,
+		"error_handling_retry_count": cfg.ErrorHandling.RetryCount,
+		"task_timeout":               cfg.Task.Timeout.String(),
+		"task_kill_grace_period":     cfg.Task.KillGracePeriod.String(),
+	}
+
+	d.sendJSONResponse(w, http.StatusOK, response)
+}
+
+func (d *Daemon) statusHandler(w http.ResponseWriter, r *http.Request) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	d.mu.RLock()
+	response := map[string]interface{}{
+		"status":           "running",
+		"uptime":           time.Since(d.startTime).String(),
+		"version":          version,
+		"build_time":       buildTime,
+		"go_version":       runtime.Version(),
+		"queued_tasks":     len(d.tasks),
+		"tasks_executed":   d.tasksExecuted,
+		"tasks_successful": d.tasksSucceeded,
+		"tasks_failed":     d.tasksFailed,
+		"memory_usage":     formatMegabytes(m.Alloc),
+	}
+	d.mu.RUnlock()
+	response["current_task"] = d.CurrentTask()
+
+	d.sendJSONResponse(w, http.StatusOK, response)
+}
+
+func (d *Daemon) sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	response := map[string]interface{}{
+		"status":  "error",
+		"message": message,
+	}
+
+	d.sendJSONResponse(w, statusCode, response)
+}
+
+func (d *Daemon) sendJSONResponse(w http.ResponseWriter, statusCode int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.WithComponent("daemon").WithError(err).Warn("レスポンスの書き込みに失敗しました")
+	}
+}
+
+// statusRecorder はレスポンスのステータスコードを記録します
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// corsMiddleware はKERUTA_DAEMON_CORS_ORIGINSで許可したオリジンからのリクエストにCORSのヘッダーを返します
+// 任意のページから/executeを呼び出せないよう、すべてのオリジン（*）は許可しません
+// 許可していないオリジンからの事前確認（プリフライト）は403で拒否し、それ以外のリクエストにはヘッダーを付与しません
+func (d *Daemon) corsMiddleware(next http.Handler) http.Handler {
+	allowed := make(map[string]bool)
+	for _, origin := range config.GetDaemonCORSOrigins() {
+		allowed[origin] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		w.Header().Add("Vary", "Origin")
+		if !allowed[origin] {
+			if preflight {
+				d.sendErrorResponse(w, http.StatusForbidden, "許可されていないオリジンです")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if preflight {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *Daemon) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		logger.WithComponent("daemon").WithFields(logrus.Fields{
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status_code": recorder.statusCode,
+			"duration":    time.Since(start),
+			"remote_addr": r.RemoteAddr,
+		}).Debug("HTTPリクエストを処理しました")
+	})
+}
+
+// validBearerToken はAuthorizationヘッダーのBearerトークンがtokenと一致するかどうかを返します
+func validBearerToken(header, token string) bool {
+	scheme, credentials, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(credentials), []byte(token)) == 1
+}
+
+// validEnvName は/executeのリクエストで指定できる環境変数の名前かどうかを返します
+func validEnvName(name string) bool {
+	suffix, ok := strings.CutPrefix(name, EnvPrefix)
+	if !ok || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatMegabytes はバイト数をMB表記の文字列に変換します
+func formatMegabytes(bytes uint64) string {
+	return strconv.FormatUint(bytes/1024/1024, 10) + "MB"
+}
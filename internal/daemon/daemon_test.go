@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -16,6 +17,21 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// useExecuteToken は/executeの認証に使うAPIトークンを設定します
+func useExecuteToken(t *testing.T) {
+	t.Helper()
+	config.GlobalConfig = &config.Config{API: config.APIConfig{Token: "test-token"}}
+	t.Cleanup(func() { config.GlobalConfig = nil })
+}
+
+// newExecuteRequest はAPIトークンで認証した/executeへのリクエストを作成します
+func newExecuteRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test-token")
+	return req
+}
+
 func TestNewDaemon(t *testing.T) {
 	// テスト用の設定を準備
 	config.GlobalConfig = &config.Config{
@@ -142,15 +158,14 @@ func TestTaskExecutionHandler(t *testing.T) {
 		"taskId": "test-task-123",
 		"script": "echo 'Hello, Daemon Test!'",
 		"environment": map[string]string{
-			"TEST_VAR": "test_value",
+			"KERUTA_PARAM_TEST_VAR": "test_value",
 		},
 	}
 
 	requestBody, err := json.Marshal(taskRequest)
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(string(requestBody)))
-	req.Header.Set("Content-Type", "application/json")
+	req := newExecuteRequest(string(requestBody))
 	w := httptest.NewRecorder()
 
 	daemon.taskExecutionHandler(w, req)
@@ -167,11 +182,11 @@ func TestTaskExecutionHandler(t *testing.T) {
 }
 
 func TestTaskExecutionHandlerInvalidRequest(t *testing.T) {
+	useExecuteToken(t)
 	daemon := &Daemon{}
 
 	// 無効なJSONリクエスト
-	req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader("invalid json"))
-	req.Header.Set("Content-Type", "application/json")
+	req := newExecuteRequest("invalid json")
 	w := httptest.NewRecorder()
 
 	daemon.taskExecutionHandler(w, req)
@@ -188,6 +203,7 @@ func TestTaskExecutionHandlerInvalidRequest(t *testing.T) {
 }
 
 func TestTaskExecutionHandlerMissingTaskID(t *testing.T) {
+	useExecuteToken(t)
 	daemon := &Daemon{}
 
 	// taskIdが欠けているリクエスト
@@ -198,8 +214,7 @@ func TestTaskExecutionHandlerMissingTaskID(t *testing.T) {
 	requestBody, err := json.Marshal(taskRequest)
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(string(requestBody)))
-	req.Header.Set("Content-Type", "application/json")
+	req := newExecuteRequest(string(requestBody))
 	w := httptest.NewRecorder()
 
 	daemon.taskExecutionHandler(w, req)
@@ -216,6 +231,7 @@ func TestTaskExecutionHandlerMissingTaskID(t *testing.T) {
 }
 
 func TestTaskExecutionHandlerMissingScript(t *testing.T) {
+	useExecuteToken(t)
 	daemon := &Daemon{}
 
 	// scriptが欠けているリクエスト
@@ -226,8 +242,7 @@ func TestTaskExecutionHandlerMissingScript(t *testing.T) {
 	requestBody, err := json.Marshal(taskRequest)
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(string(requestBody)))
-	req.Header.Set("Content-Type", "application/json")
+	req := newExecuteRequest(string(requestBody))
 	w := httptest.NewRecorder()
 
 	daemon.taskExecutionHandler(w, req)
@@ -244,7 +259,8 @@ func TestTaskExecutionHandlerMissingScript(t *testing.T) {
 }
 
 func TestMetricsHandler(t *testing.T) {
-	daemon := &Daemon{}
+	daemon := NewDaemonWithAddress("localhost", "0")
+	daemon.SetCurrentTask(&TaskInfo{TaskID: "metrics-task", Source: "poll", StartedAt: time.Now()})
 
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	w := httptest.NewRecorder()
@@ -253,18 +269,19 @@ func TestMetricsHandler(t *testing.T) {
 
 	resp := w.Result()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
 
-	var response map[string]interface{}
-	err := json.NewDecoder(resp.Body).Decode(&response)
+	body, err := io.ReadAll(resp.Body)
 	require.NoError(t, err)
 
-	// メトリクスの基本フィールドが存在することを確認
-	assert.Contains(t, response, "uptime")
-	assert.Contains(t, response, "tasks_executed")
-	assert.Contains(t, response, "tasks_successful")
-	assert.Contains(t, response, "tasks_failed")
-	assert.Contains(t, response, "memory_usage")
-	assert.Contains(t, response, "cpu_usage")
+	// Prometheusのテキスト形式でデーモンの状態とエージェントのメトリクスが返されることを確認
+	text := string(body)
+	assert.Contains(t, text, "# TYPE keruta_daemon_uptime_seconds gauge")
+	assert.Contains(t, text, "keruta_daemon_queued_tasks 0")
+	assert.Contains(t, text, "keruta_daemon_task_running 1")
+	assert.Contains(t, text, "# TYPE keruta_tasks_executed_total counter")
+	assert.Contains(t, text, "# TYPE keruta_log_queue_depth gauge")
+	assert.Contains(t, text, "go_goroutines")
 }
 
 func TestConfigHandler(t *testing.T) {
@@ -338,6 +355,10 @@ func TestStatusHandler(t *testing.T) {
 	assert.Contains(t, response, "version")
 	assert.Contains(t, response, "build_time")
 	assert.Contains(t, response, "go_version")
+	assert.Contains(t, response, "tasks_executed")
+	assert.Contains(t, response, "tasks_successful")
+	assert.Contains(t, response, "tasks_failed")
+	assert.Contains(t, response, "memory_usage")
 
 	// アップタイムが正の値であることを確認
 	uptime, ok := response["uptime"].(string)
@@ -390,235 +411,199 @@ func TestMiddlewareLogging(t *testing.T) {
 }
 
 func TestCORSMiddleware(t *testing.T) {
-	daemon := &Daemon{}
+	t.Setenv("KERUTA_DAEMON_CORS_ORIGINS", "http://allowed.example.com, http://other.example.com")
+	daemon := NewDaemonWithAddress("localhost", "0")
+	handler := daemon.handler()
+
+	preflight := func(origin string) *http.Response {
+		req := httptest.NewRequest(http.MethodOptions, "/execute", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result()
+	}
 
-	// テスト用のハンドラー
-	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// 許可したオリジンからの事前確認（プリフライト）にはCORSのヘッダーを返す
+	resp := preflight("http://allowed.example.com")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "http://allowed.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, OPTIONS", resp.Header.Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", resp.Header.Get("Access-Control-Allow-Headers"))
 
-	// CORSミドルウェアを適用
-	wrappedHandler := daemon.corsMiddleware(testHandler)
+	// 許可していないオリジンは拒否する
+	resp = preflight("http://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
 
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	req.Header.Set("Origin", "http://example.com")
+	// 通常のリクエストにも許可したオリジンの場合のみヘッダーを付与する
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "http://other.example.com")
 	w := httptest.NewRecorder()
-
-	wrappedHandler.ServeHTTP(w, req)
-
-	resp := w.Result()
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-
-	// CORSヘッダーが設定されていることを確認
-	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
-	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", resp.Header.Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Content-Type, Authorization", resp.Header.Get("Access-Control-Allow-Headers"))
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://other.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	req = httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "http://evil.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
 }
 
-func TestOPTIONSRequest(t *testing.T) {
-	daemon := &Daemon{}
-
-	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	wrappedHandler := daemon.corsMiddleware(testHandler)
+func TestOPTIONSRequestWithoutAllowedOrigins(t *testing.T) {
+	t.Setenv("KERUTA_DAEMON_CORS_ORIGINS", "")
+	daemon := NewDaemonWithAddress("localhost", "0")
 
-	// OPTIONSリクエスト
-	req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+	// 許可するオリジンを設定しない場合は、ブラウザの事前確認（プリフライト）を許可しない
+	req := httptest.NewRequest(http.MethodOptions, "/execute", nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
 	w := httptest.NewRecorder()
 
-	wrappedHandler.ServeHTTP(w, req)
+	daemon.handler().ServeHTTP(w, req)
 
 	resp := w.Result()
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
-
-	// CORSヘッダーが設定されていることを確認
-	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
-	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", resp.Header.Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Content-Type, Authorization", resp.Header.Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Methods"))
 }
 
-// ヘルパー構造体とメソッドの実装（実際のdaemonパッケージにも実装が必要）
-
-type Daemon struct {
-	port      string
-	server    *http.Server
-	startTime time.Time
-}
-
-func NewDaemon() *Daemon {
-	port := config.GetDaemonPort()
-	return &Daemon{
-		port:      port,
-		startTime: time.Now(),
+func TestTaskExecutionHandlerRejectsUnauthorized(t *testing.T) {
+	body := `{"taskId": "task-123", "script": "true"}`
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{"トークン未設定", "", "Bearer ", http.StatusForbidden},
+		{"ヘッダーなし", "test-token", "", http.StatusUnauthorized},
+		{"トークンが異なる", "test-token", "Bearer other-token", http.StatusUnauthorized},
+		{"Bearer以外の認証方式", "test-token", "Basic test-token", http.StatusUnauthorized},
 	}
-}
-
-func (d *Daemon) Start(ctx context.Context) error {
-	mux := http.NewServeMux()
-
-	// ルートハンドラーを設定
-	mux.HandleFunc("/health", d.healthCheckHandler)
-	mux.HandleFunc("/execute", d.taskExecutionHandler)
-	mux.HandleFunc("/metrics", d.metricsHandler)
-	mux.HandleFunc("/config", d.configHandler)
-	mux.HandleFunc("/status", d.statusHandler)
-
-	// ミドルウェアを適用
-	handler := d.loggingMiddleware(d.corsMiddleware(mux))
-
-	d.server = &http.Server{
-		Addr:    ":" + d.port,
-		Handler: handler,
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.GlobalConfig = &config.Config{API: config.APIConfig{Token: tt.token}}
+			t.Cleanup(func() { config.GlobalConfig = nil })
+			daemon := NewDaemonWithAddress("localhost", "0")
+
+			req := httptest.NewRequest(http.MethodPost, "/execute", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			daemon.taskExecutionHandler(w, req)
+			assert.Equal(t, tt.want, w.Result().StatusCode)
+			assert.Empty(t, daemon.Tasks())
+		})
 	}
-
-	// バックグラウンドでサーバーを開始
-	go func() {
-		if err := d.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			// エラーログ
-		}
-	}()
-
-	// コンテキストがキャンセルされるまで待機
-	<-ctx.Done()
-
-	// グレースフルシャットダウン
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	return d.server.Shutdown(shutdownCtx)
-}
-
-func (d *Daemon) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"status":    "ok",
-		"timestamp": time.Now().Format(time.RFC3339),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
 
-func (d *Daemon) taskExecutionHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		d.sendErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
-	var request map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		d.sendErrorResponse(w, http.StatusBadRequest, "リクエストの解析に失敗しました: "+err.Error())
-		return
-	}
+func TestTaskExecutionHandlerRejectsNonJSON(t *testing.T) {
+	useExecuteToken(t)
+	daemon := NewDaemonWithAddress("localhost", "0")
 
-	taskID, ok := request["taskId"].(string)
-	if !ok || taskID == "" {
-		d.sendErrorResponse(w, http.StatusBadRequest, "taskIdが指定されていません")
-		return
-	}
+	// フォームの送信はブラウザから事前確認なしに送信できる
+	req := newExecuteRequest(`{"taskId": "task-123", "script": "true"}`)
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
 
-	script, ok := request["script"].(string)
-	if !ok || script == "" {
-		d.sendErrorResponse(w, http.StatusBadRequest, "scriptが指定されていません")
-		return
-	}
+	daemon.taskExecutionHandler(w, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Result().StatusCode)
+	assert.Empty(t, daemon.Tasks())
 
-	// タスクを非同期実行（実際の実装では goroutine で実行）
-	// ここではテスト用に即座にレスポンスを返す
-	response := map[string]interface{}{
-		"status": "accepted",
-		"taskId": taskID,
-	}
+	// パラメータ付きのapplication/jsonは受け付ける
+	req = newExecuteRequest(`{"taskId": "task-123", "script": "true"}`)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w = httptest.NewRecorder()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	daemon.taskExecutionHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 }
 
-func (d *Daemon) metricsHandler(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"uptime":           time.Since(d.startTime).String(),
-		"tasks_executed":   0,
-		"tasks_successful": 0,
-		"tasks_failed":     0,
-		"memory_usage":     "0MB",
-		"cpu_usage":        "0%",
+func TestTaskExecutionHandlerRejectsEnvironment(t *testing.T) {
+	useExecuteToken(t)
+
+	for _, key := range []string{"PATH", "LD_PRELOAD", "KERUTA_PARAM_", "KERUTA_PARAM_foo", "KERUTA_PARAM_A=B"} {
+		t.Run(key, func(t *testing.T) {
+			daemon := NewDaemonWithAddress("localhost", "0")
+			body, err := json.Marshal(map[string]interface{}{
+				"taskId":      "task-123",
+				"script":      "true",
+				"environment": map[string]string{key: "value"},
+			})
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			daemon.taskExecutionHandler(w, newExecuteRequest(string(body)))
+			assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+			assert.Contains(t, w.Body.String(), "指定できません")
+			assert.Empty(t, daemon.Tasks())
+		})
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
 
-func (d *Daemon) configHandler(w http.ResponseWriter, r *http.Request) {
-	cfg := config.GlobalConfig
-	if cfg == nil {
-		d.sendErrorResponse(w, http.StatusInternalServerError, "設定が初期化されていません")
-		return
-	}
+func TestTaskExecutionHandlerEnqueue(t *testing.T) {
+	useExecuteToken(t)
+	daemon := NewDaemonWithAddress("localhost", "0")
 
-	response := map[string]interface{}{
-		"api_url":                    cfg.API.URL,
-		"api_token":                  "***", // セキュリティのためマスク
-		"api_timeout":                cfg.API.Timeout.String(),
-		"log_level":                  cfg.Logging.Level,
-		"log_format":                 cfg.Logging.Format,
-		"artifacts_max_size":         cfg.Artifacts.MaxSize,
-		"artifacts_directory":        cfg.Artifacts.Directory,
-		"error_handling_auto_fix":    cfg.ErrorHandling.AutoFix,
-		"error_handling_retry_count": cfg.ErrorHandling.RetryCount,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
+	requestBody := `{"taskId": "queued-task-123", "script": "echo queued", "environment": {"KERUTA_PARAM_FOO": "bar"}}`
+	req := newExecuteRequest(requestBody)
+	w := httptest.NewRecorder()
 
-func (d *Daemon) statusHandler(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"status":     "running",
-		"uptime":     time.Since(d.startTime).String(),
-		"version":    "1.0.0",
-		"build_time": "2023-07-29T12:00:00Z",
-		"go_version": "go1.20",
+	daemon.taskExecutionHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	// 実行キューにタスクが投入されていることを確認
+	select {
+	case queued := <-daemon.Tasks():
+		assert.Equal(t, "queued-task-123", queued.TaskID)
+		assert.Equal(t, "echo queued", queued.Script)
+		assert.Equal(t, "bar", queued.Environment["KERUTA_PARAM_FOO"])
+	default:
+		t.Fatal("タスクがキューに投入されていません")
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
 
-func (d *Daemon) sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	response := map[string]interface{}{
-		"status":  "error",
-		"message": message,
+func TestTaskExecutionHandlerQueueFull(t *testing.T) {
+	useExecuteToken(t)
+	daemon := NewDaemonWithAddress("localhost", "0")
+	for i := 0; i < taskQueueSize; i++ {
+		daemon.tasks <- &TaskRequest{TaskID: "filler", Script: "true"}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(response)
-}
+	req := newExecuteRequest(`{"taskId": "overflow", "script": "true"}`)
+	w := httptest.NewRecorder()
 
-func (d *Daemon) loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		duration := time.Since(start)
+	daemon.taskExecutionHandler(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+}
 
-		// ログ出力（実際の実装ではloggerを使用）
-		_ = duration
+func TestStatusHandlerCurrentTask(t *testing.T) {
+	daemon := NewDaemonWithAddress("localhost", "0")
+	daemon.SetCurrentTask(&TaskInfo{
+		TaskID:    "running-task-123",
+		Source:    "poll",
+		StartedAt: time.Now(),
 	})
-}
 
-func (d *Daemon) corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	w := httptest.NewRecorder()
+	daemon.statusHandler(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&response))
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	currentTask, ok := response["current_task"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "running-task-123", currentTask["taskId"])
 
-		next.ServeHTTP(w, r)
-	})
+	// タスク完了後は実行中のタスクがなくなり、集計に反映される
+	daemon.FinishCurrentTask(true)
+	assert.Nil(t, daemon.CurrentTask())
+	assert.Equal(t, int64(1), daemon.tasksSucceeded)
 }
@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ClaudeExecutor はタスクの内容をClaudeに渡して実行するランナーです
+type ClaudeExecutor struct {
+	Command string
+	Args    []string
+}
+
+// NewClaudeExecutor は新しいClaudeExecutorを作成します
+func NewClaudeExecutor() *ClaudeExecutor {
+	return &ClaudeExecutor{
+		Command: "claude",
+		Args:    []string{"--dangerously-skip-permissions"},
+	}
+}
+
+// Name はランナーの名前を返します
+func (e *ClaudeExecutor) Name() string {
+	return "claude"
+}
+
+// Execute は作業ディレクトリでClaudeを実行します
+func (e *ClaudeExecutor) Execute(ctx context.Context, req *Request) error {
+	req.Logger.Info("🎯 環境でClaude実行タスクを開始しています...")
+
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Stdin = req.Prompt
+	cmd.Dir = req.WorkDir
+	cmd.Env = append(os.Environ(), req.Env...)
+
+	req.Logger.WithFields(logrus.Fields{
+		"working_dir": req.WorkDir,
+		"command":     cmd.Args,
+	}).Info("🖥️ コマンドを構築しました")
+
+	if err := runCommand(ctx, cmd, req, e.Name()); err != nil {
+		return err
+	}
+
+	req.Logger.Info("✅ Claude実行タスクが完了しました")
+	return nil
+}
@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"keruta-agent/internal/api"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrTimeout はタスクが最大実行時間を超えたため停止されたことを表します
+var ErrTimeout = errors.New("タスクの実行がタイムアウトしました")
+
+// defaultKillGracePeriod はKillGracePeriodが指定されていない場合の猶予時間です
+const defaultKillGracePeriod = 10 * time.Second
+
+// OutputSender は実行中の出力をAPIに送信するためのインターフェースです
+type OutputSender interface {
+	SendLog(taskID string, level string, message string) error
+	logBatchSender
+}
+
+// Request はタスクの実行に必要な情報を表します
+type Request struct {
+	TaskID  string
+	WorkDir string
+	Script  api.Script
+	// Prompt はClaudeに標準入力として渡すタスクの内容です
+	Prompt io.Reader
+	// Env はプロセスに追加で渡す環境変数です（KEY=VALUE形式）
+	Env    []string
+	Output OutputSender
+	Logger *logrus.Entry
+	// KillGracePeriod はSIGTERM送信後、SIGKILLを送信するまでの猶予時間です
+	KillGracePeriod time.Duration
+	// OnStart はプロセスの開始後に、プロセスグループID（子プロセスのPID）を受け取ります
+	OnStart func(pgid int)
+}
+
+func (r *Request) killGracePeriod() time.Duration {
+	if r.KillGracePeriod <= 0 {
+		return defaultKillGracePeriod
+	}
+	return r.KillGracePeriod
+}
+
+// Executor はタスクを実行するランナーのインターフェースです
+type Executor interface {
+	// Name はランナーの名前を返します
+	Name() string
+	// Execute はタスクを実行し、終了するまでブロックします
+	Execute(ctx context.Context, req *Request) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Executor)
+)
+
+// Register は指定した言語のランナーを登録します。同じ言語が登録済みの場合は上書きします
+func Register(executor Executor, languages ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, language := range languages {
+		registry[normalizeLanguage(language)] = executor
+	}
+}
+
+// Lookup は言語に対応するランナーを返します。言語が空の場合はClaudeのランナーを返します
+func Lookup(language string) (Executor, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	executor, ok := registry[normalizeLanguage(language)]
+	if !ok {
+		return nil, fmt.Errorf("サポートされていない言語です: %s", language)
+	}
+	return executor, nil
+}
+
+// Languages は登録されている言語の一覧を返します
+func Languages() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	languages := make([]string, 0, len(registry))
+	for language := range registry {
+		if language != "" {
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func normalizeLanguage(language string) string {
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+func init() {
+	Register(NewClaudeExecutor(), "", "claude")
+	Register(NewShellExecutor("bash"), "bash", "shell")
+	Register(NewShellExecutor("sh"), "sh")
+	Register(NewPythonExecutor(), "python", "python3", "py")
+}
@@ -0,0 +1,219 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"keruta-agent/internal/api"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOutputSender は送信されたログを記録するテスト用の送信先です
+type mockOutputSender struct {
+	mockLogBatchSender
+	logs []api.LogRequest
+}
+
+func (m *mockOutputSender) SendLog(taskID string, level string, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs = append(m.logs, api.LogRequest{Level: level, Message: message})
+	return nil
+}
+
+func newTestRequest(t *testing.T, script api.Script) (*Request, *mockOutputSender) {
+	output := &mockOutputSender{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return &Request{
+		TaskID:  "executor-task",
+		WorkDir: t.TempDir(),
+		Script:  script,
+		Output:  output,
+		Logger:  logrus.NewEntry(logger),
+	}, output
+}
+
+func TestLookup(t *testing.T) {
+	for language, expected := range map[string]string{
+		"":        "claude",
+		"claude":  "claude",
+		"bash":    "bash",
+		"Shell":   "bash",
+		"sh":      "sh",
+		"python":  "python3",
+		"python3": "python3",
+	} {
+		runner, err := Lookup(language)
+		require.NoError(t, err, language)
+		assert.Equal(t, expected, runner.Name(), language)
+	}
+
+	_, err := Lookup("cobol")
+	assert.Error(t, err)
+}
+
+type stubExecutor struct{}
+
+func (stubExecutor) Name() string                                    { return "stub" }
+func (stubExecutor) Execute(ctx context.Context, req *Request) error { return nil }
+
+func TestRegister(t *testing.T) {
+	Register(stubExecutor{}, "Stub-Lang")
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "stub-lang")
+		registryMu.Unlock()
+	})
+
+	runner, err := Lookup("stub-lang")
+	require.NoError(t, err)
+	assert.Equal(t, "stub", runner.Name())
+	assert.Contains(t, Languages(), "stub-lang")
+}
+
+func TestShellExecutor(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash command not available")
+	}
+
+	req, output := newTestRequest(t, api.Script{
+		Content:    "echo \"dir=$(basename \"$PWD\")\"\necho \"name=$KERUTA_PARAM_TARGET_NAME\"\necho \"env=$KERUTA_PARAM_MODE\"\necho oops >&2\n",
+		Language:   "bash",
+		Filename:   "setup.sh",
+		Parameters: map[string]interface{}{"target-name": "web"},
+	})
+	req.Env = []string{"KERUTA_PARAM_MODE=dry-run"}
+
+	runner, err := Lookup("bash")
+	require.NoError(t, err)
+	require.NoError(t, runner.Execute(context.Background(), req))
+
+	// 標準出力と標準エラー出力の間の順序は保証されないため、それぞれの順序のみ確認する
+	var stdoutLogs, stderrLogs []api.LogRequest
+	for _, log := range output.all() {
+		if strings.HasPrefix(log.Message, "[:stderr]") {
+			stderrLogs = append(stderrLogs, log)
+		} else {
+			stdoutLogs = append(stdoutLogs, log)
+		}
+	}
+	require.Len(t, stdoutLogs, 3)
+	assert.Equal(t, "[:stdout] dir="+filepath.Base(req.WorkDir), stdoutLogs[0].Message)
+	assert.Equal(t, "[:stdout] name=web", stdoutLogs[1].Message)
+	assert.Equal(t, "[:stdout] env=dry-run", stdoutLogs[2].Message)
+	require.Len(t, stderrLogs, 1)
+	assert.Equal(t, "[:stderr] oops", stderrLogs[0].Message)
+	assert.Equal(t, "INFO", stderrLogs[0].Level)
+}
+
+func TestShellExecutorFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh command not available")
+	}
+
+	req, output := newTestRequest(t, api.Script{Content: "exit 3", Language: "sh"})
+
+	runner, err := Lookup("sh")
+	require.NoError(t, err)
+	err = runner.Execute(context.Background(), req)
+	assert.Error(t, err)
+
+	require.NotEmpty(t, output.logs)
+	assert.True(t, strings.HasPrefix(output.logs[len(output.logs)-1].Message, "[:start-cmd] コマンドが異常終了しました"))
+}
+
+func TestPythonExecutor(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 command not available")
+	}
+
+	req, output := newTestRequest(t, api.Script{Content: "print('hello from python')", Language: "python"})
+
+	runner, err := Lookup("python")
+	require.NoError(t, err)
+	require.NoError(t, runner.Execute(context.Background(), req))
+
+	logs := output.all()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "[:stdout] hello from python", logs[0].Message)
+}
+
+func TestShellExecutorTimeoutKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are not supported on windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash command not available")
+	}
+
+	// SIGTERMを無視する孫プロセスを含むスクリプトは、猶予時間後にSIGKILLで停止される
+	req, _ := newTestRequest(t, api.Script{
+		Content:  "trap '' TERM\nsleep 30 &\nsleep 30\n",
+		Language: "bash",
+	})
+	req.KillGracePeriod = 200 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	runner, err := Lookup("bash")
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = runner.Execute(ctx, req)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestShellExecutorTimeoutKillsDetachedGrandchild(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process state is read from /proc")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash command not available")
+	}
+
+	// 出力を保持せずにSIGTERMを無視する孫プロセスは、リーダーが終了した後もSIGKILLで停止される
+	req, output := newTestRequest(t, api.Script{
+		Content:  "(trap '' TERM; exec sleep 30) </dev/null >/dev/null 2>&1 &\necho \"$!\"\nsleep 30\n",
+		Language: "bash",
+	})
+	req.KillGracePeriod = 300 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	runner, err := Lookup("bash")
+	require.NoError(t, err)
+	assert.ErrorIs(t, runner.Execute(ctx, req), ErrTimeout)
+
+	var pid int
+	for _, log := range output.all() {
+		if value, ok := strings.CutPrefix(log.Message, "[:stdout] "); ok {
+			pid, err = strconv.Atoi(value)
+			require.NoError(t, err)
+		}
+	}
+	require.NotZero(t, pid)
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return true
+		}
+		// 回収されていない終了済みのプロセス（ゾンビ）
+		fields := strings.Fields(string(data)[strings.LastIndexByte(string(data), ')')+1:])
+		return len(fields) > 0 && fields[0] == "Z"
+	}, 2*time.Second, 50*time.Millisecond)
+}
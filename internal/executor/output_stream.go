@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"keruta-agent/internal/api"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// outputSourceStdout は標準出力由来のログに付与するタグです
+	outputSourceStdout = "stdout"
+	// outputSourceStderr は標準エラー出力由来のログに付与するタグです
+	outputSourceStderr = "stderr"
+
+	// defaultLogBatchSize は一度に送信するログの最大件数です
+	defaultLogBatchSize = 50
+	// defaultLogFlushInterval はバッファ済みのログを送信する間隔です
+	defaultLogFlushInterval = time.Second
+)
+
+// logBatchSender はログを一括送信するためのインターフェースです
+type logBatchSender interface {
+	SendLogBatch(taskID string, logs []api.LogRequest) error
+}
+
+// outputStreamer はコマンドの出力を1行ずつ読み取り、まとめてAPIに送信します
+// 送信は単一のゴルーチンで行うため、ログの順序は保たれます
+type outputStreamer struct {
+	sender        logBatchSender
+	taskID        string
+	runner        string
+	logger        *logrus.Entry
+	batchSize     int
+	flushInterval time.Duration
+
+	lines chan api.LogRequest
+	done  chan struct{}
+}
+
+// newOutputStreamer は新しいoutputStreamerを作成し、送信ゴルーチンを開始します
+// runnerはログの送信元（例: claude-stdout）の接頭辞として使われます
+func newOutputStreamer(sender logBatchSender, taskID, runner string, logger *logrus.Entry) *outputStreamer {
+	return newOutputStreamerWithOptions(sender, taskID, runner, logger, defaultLogBatchSize, defaultLogFlushInterval)
+}
+
+// newOutputStreamerWithOptions はバッチサイズと送信間隔を指定してoutputStreamerを作成します
+func newOutputStreamerWithOptions(sender logBatchSender, taskID, runner string, logger *logrus.Entry, batchSize int, flushInterval time.Duration) *outputStreamer {
+	s := &outputStreamer{
+		sender:        sender,
+		taskID:        taskID,
+		runner:        runner,
+		logger:        logger,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		lines:         make(chan api.LogRequest, batchSize*4),
+		done:          make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Stream はreaderから1行ずつ読み取り、送信キューに追加します。readerがEOFに達するまでブロックします
+func (s *outputStreamer) Stream(reader io.Reader, source string, level string) error {
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			// ローカルにはDebugで出力（APIへの二重送信を避けるため）
+			s.logger.WithField("source", source).Debug(line)
+			// 時刻とシーケンス番号は読み取った時点で付与し、送信が遅れても出力順に並べられるようにする
+			log := api.NewLogRequest(s.runner+"-"+source, level, fmt.Sprintf("[:%s] %s", source, line))
+			log.Fields = map[string]interface{}{"stream": source}
+			s.lines <- log
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Close は送信キューを閉じ、残りのログをすべて送信してから戻ります
+func (s *outputStreamer) Close() {
+	close(s.lines)
+	<-s.done
+}
+
+func (s *outputStreamer) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]api.LogRequest, 0, s.batchSize)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, line)
+			if len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = make([]api.LogRequest, 0, s.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(batch)
+				batch = make([]api.LogRequest, 0, s.batchSize)
+			}
+		}
+	}
+}
+
+func (s *outputStreamer) flush(batch []api.LogRequest) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.sender.SendLogBatch(s.taskID, batch); err != nil {
+		s.logger.WithError(err).WithField("count", len(batch)).Warning("出力ログの送信に失敗しました")
+	}
+}
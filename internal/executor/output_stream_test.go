@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"keruta-agent/internal/api"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLogBatchSender は送信されたバッチを記録するテスト用の送信先です
+type mockLogBatchSender struct {
+	mu      sync.Mutex
+	batches [][]api.LogRequest
+}
+
+func (m *mockLogBatchSender) SendLogBatch(taskID string, logs []api.LogRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches = append(m.batches, append([]api.LogRequest(nil), logs...))
+	return nil
+}
+
+func (m *mockLogBatchSender) all() []api.LogRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var logs []api.LogRequest
+	for _, batch := range m.batches {
+		logs = append(logs, batch...)
+	}
+	return logs
+}
+
+func TestOutputStreamerBatchesLines(t *testing.T) {
+	sender := &mockLogBatchSender{}
+	streamer := newOutputStreamerWithOptions(sender, "stream-task", "claude", logrus.NewEntry(logrus.New()), 2, time.Hour)
+
+	err := streamer.Stream(strings.NewReader("line 1\nline 2\r\n\nline 3"), outputSourceStdout, "INFO")
+	require.NoError(t, err)
+	streamer.Close()
+
+	logs := sender.all()
+	require.Len(t, logs, 3)
+	assert.Equal(t, "[:stdout] line 1", logs[0].Message)
+	assert.Equal(t, "[:stdout] line 2", logs[1].Message)
+	assert.Equal(t, "[:stdout] line 3", logs[2].Message)
+	assert.Equal(t, "INFO", logs[0].Level)
+	assert.Equal(t, api.LogSourceClaudeStdout, logs[0].Source)
+	assert.Equal(t, "stdout", logs[0].Fields["stream"])
+
+	// シーケンス番号は読み取った順に増加し、時刻も付与される
+	for i := 1; i < len(logs); i++ {
+		assert.Greater(t, logs[i].Sequence, logs[i-1].Sequence)
+		assert.False(t, logs[i].Timestamp.IsZero())
+	}
+
+	// バッチサイズ2で分割され、最後の1行はClose時に送信される
+	require.Len(t, sender.batches, 2)
+	assert.Len(t, sender.batches[0], 2)
+	assert.Len(t, sender.batches[1], 1)
+}
+
+func TestOutputStreamerFlushesOnInterval(t *testing.T) {
+	sender := &mockLogBatchSender{}
+	streamer := newOutputStreamerWithOptions(sender, "stream-task", "claude", logrus.NewEntry(logrus.New()), 100, 10*time.Millisecond)
+	defer streamer.Close()
+
+	require.NoError(t, streamer.Stream(strings.NewReader("error output\n"), outputSourceStderr, "ERROR"))
+
+	// バッチサイズに達しなくても一定間隔で送信される
+	assert.Eventually(t, func() bool {
+		return len(sender.all()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "[:stderr] error output", sender.all()[0].Message)
+	assert.Equal(t, "ERROR", sender.all()[0].Level)
+	assert.Equal(t, api.LogSourceClaudeStderr, sender.all()[0].Source)
+}
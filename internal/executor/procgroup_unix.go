@@ -0,0 +1,57 @@
+//go:build !windows
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup はコマンドを独自のプロセスグループで実行するように設定します
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup はプロセスグループ全体にSIGTERMを送信します
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return TerminateProcessGroup(cmd.Process.Pid)
+}
+
+// killProcessGroup はプロセスグループ全体にSIGKILLを送信します
+func killProcessGroup(cmd *exec.Cmd) error {
+	return KillProcessGroup(cmd.Process.Pid)
+}
+
+// processGroupExists はプロセスグループにプロセスが残っているかどうかを返します
+func processGroupExists(cmd *exec.Cmd) bool {
+	err := syscall.Kill(-cmd.Process.Pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// TerminateProcessGroup はpgidのプロセスグループ全体にSIGTERMを送信します
+// プロセスグループが既に存在しない場合は何もしません
+func TerminateProcessGroup(pgid int) error {
+	return signalProcessGroup(pgid, syscall.SIGTERM)
+}
+
+// KillProcessGroup はpgidのプロセスグループ全体にSIGKILLを送信します
+// プロセスグループが既に存在しない場合は何もしません
+func KillProcessGroup(pgid int) error {
+	return signalProcessGroup(pgid, syscall.SIGKILL)
+}
+
+func signalProcessGroup(pgid int, sig syscall.Signal) error {
+	// 0や1を指定すると、エージェント自身のプロセスグループや全プロセスにシグナルを送信してしまう
+	if pgid <= 1 {
+		return fmt.Errorf("プロセスグループIDが不正です: %d", pgid)
+	}
+	if err := syscall.Kill(-pgid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
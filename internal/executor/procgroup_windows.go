@@ -0,0 +1,47 @@
+//go:build windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup はコマンドを新しいプロセスグループで実行するように設定します
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// terminateProcessGroup はプロセスを終了します（Windowsにはシグナルによる猶予がないため即時終了）
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcessGroup はプロセスを強制終了します
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// processGroupExists はプロセスグループにプロセスが残っているかどうかを返します
+// Windowsではプロセスを即時終了するため、常に残っていないものとして扱います
+func processGroupExists(cmd *exec.Cmd) bool {
+	return false
+}
+
+// TerminateProcessGroup はpgidのプロセスを終了します（Windowsにはシグナルによる猶予がないため即時終了）
+func TerminateProcessGroup(pgid int) error {
+	return KillProcessGroup(pgid)
+}
+
+// KillProcessGroup はpgidのプロセスを強制終了します。プロセスが既に存在しない場合は何もしません
+func KillProcessGroup(pgid int) error {
+	process, err := os.FindProcess(pgid)
+	if err != nil {
+		return nil
+	}
+	return process.Kill()
+}
@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"os/exec"
+	"time"
+
+	"keruta-agent/internal/metrics"
+)
+
+// processSampleInterval は実行中のプロセスのCPU時間とメモリ使用量を記録する間隔です
+const processSampleInterval = 5 * time.Second
+
+// watchProcess は実行中のプロセスのCPU時間と常駐メモリサイズを定期的にメトリクスに記録します
+// 返り値の関数はプロセスの終了後に呼び出し、記録を停止して使用したCPU時間の合計を記録します
+func watchProcess(cmd *exec.Cmd, runner string) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(processSampleInterval)
+		defer ticker.Stop()
+		for {
+			if stats, err := readProcessStats(cmd.Process.Pid); err == nil {
+				metrics.SetProcessUsage(runner, stats.cpuSeconds, stats.rssBytes)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		var cpuSeconds float64
+		if state := cmd.ProcessState; state != nil {
+			cpuSeconds = (state.UserTime() + state.SystemTime()).Seconds()
+		}
+		metrics.FinishProcess(runner, cpuSeconds)
+	}
+}
+
+// processStats はプロセスのリソース使用量です
+type processStats struct {
+	cpuSeconds float64
+	rssBytes   uint64
+}
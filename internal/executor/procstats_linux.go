@@ -0,0 +1,33 @@
+//go:build linux
+
+package executor
+
+import (
+	"os"
+
+	"keruta-agent/internal/procfs"
+)
+
+// clockTicksPerSecond は/proc/[pid]/statのCPU時間の単位です（LinuxのUSER_HZは100固定）
+const clockTicksPerSecond = 100
+
+// readProcessStats は/proc/[pid]/statからプロセスのCPU時間と常駐メモリサイズを読み込みます
+func readProcessStats(pid int) (processStats, error) {
+	stat, err := procfs.ReadStat(pid)
+	if err != nil {
+		return processStats{}, err
+	}
+	return newProcessStats(stat), nil
+}
+
+// newProcessStats は/proc/[pid]/statの情報をCPU時間（秒）と常駐メモリサイズ（バイト）に変換します
+func newProcessStats(stat procfs.Stat) processStats {
+	rss := stat.RSS
+	if rss < 0 {
+		rss = 0
+	}
+	return processStats{
+		cpuSeconds: float64(stat.Utime+stat.Stime) / clockTicksPerSecond,
+		rssBytes:   uint64(rss) * uint64(os.Getpagesize()),
+	}
+}
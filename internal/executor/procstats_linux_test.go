@@ -0,0 +1,28 @@
+//go:build linux
+
+package executor
+
+import (
+	"os"
+	"testing"
+
+	"keruta-agent/internal/procfs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProcessStats(t *testing.T) {
+	stats := newProcessStats(procfs.Stat{Utime: 250, Stime: 50, RSS: 2048})
+	assert.Equal(t, 3.0, stats.cpuSeconds)
+	assert.Equal(t, uint64(2048*os.Getpagesize()), stats.rssBytes)
+
+	// カーネルスレッドなどでrssが負の値の場合は0として扱う
+	assert.Zero(t, newProcessStats(procfs.Stat{RSS: -1}).rssBytes)
+}
+
+func TestReadProcessStatsSelf(t *testing.T) {
+	stats, err := readProcessStats(os.Getpid())
+	require.NoError(t, err)
+	assert.Positive(t, stats.rssBytes)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package executor
+
+import "errors"
+
+// readProcessStats は実行中のプロセスのリソース使用量を読み込みます
+// Linux以外では対応していないため、終了時のCPU時間のみを記録します
+func readProcessStats(pid int) (processStats, error) {
+	return processStats{}, errors.New("プロセス情報の取得に対応していません")
+}
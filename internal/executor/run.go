@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// waitDelayMargin はSIGKILL送信後、出力の読み取りを打ち切るまでの追加の待ち時間です
+const waitDelayMargin = 5 * time.Second
+
+// processGroupPollInterval はSIGTERM送信後に、プロセスグループのプロセスが終了したかどうかを確認する間隔です
+const processGroupPollInterval = 50 * time.Millisecond
+
+// runCommand はコマンドを実行し、標準出力・標準エラー出力を1行ずつAPIに送信します
+// 出力ログの送信元はrunnerの名前から決まります（例: claude-stdout）
+// ctxが終了した場合はプロセスグループ全体を停止します
+func runCommand(ctx context.Context, cmd *exec.Cmd, req *Request, runner string) error {
+	logger := req.Logger
+	logger.Info("🚀セッションを起動しています...")
+
+	// セッション開始
+	logger.WithFields(logrus.Fields{
+		"command": strings.Join(cmd.Args, " "),
+	}).Info("⚡ セッションを開始します")
+
+	// パイプはWaitの後に閉じ、孫プロセスが出力を保持していても読み取りが終わるようにする
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+
+	// キャンセル・タイムアウト時はプロセスグループ全体にSIGTERMを送り、猶予時間後にSIGKILLを送る
+	grace := req.killGracePeriod()
+	setProcessGroup(cmd)
+	var (
+		killMu       sync.Mutex
+		killTimer    *time.Timer
+		killDeadline time.Time
+	)
+	killGroup := func() {
+		logger.Warn("猶予時間内に終了しなかったため、プロセスグループにSIGKILLを送信します")
+		if err := killProcessGroup(cmd); err != nil {
+			logger.WithError(err).Debug("SIGKILLの送信に失敗しました")
+		}
+	}
+	cmd.Cancel = func() error {
+		logger.WithField("grace_period", grace).Warn("🛑 プロセスグループにSIGTERMを送信します")
+		killMu.Lock()
+		killDeadline = time.Now().Add(grace)
+		killTimer = time.AfterFunc(grace, killGroup)
+		killMu.Unlock()
+		return terminateProcessGroup(cmd)
+	}
+	cmd.WaitDelay = grace + waitDelayMargin
+
+	if err := cmd.Start(); err != nil {
+		if sendErr := req.Output.SendLog(req.TaskID, "ERROR", fmt.Sprintf("[:start-cmd] %v", err)); sendErr != nil {
+			logger.WithError(sendErr).Warning("開始エラーログ送信に失敗しました")
+		}
+		return fmt.Errorf("セッション開始に失敗: %w", err)
+	}
+	finishProcess := watchProcess(cmd, runner)
+	if req.OnStart != nil {
+		req.OnStart(cmd.Process.Pid)
+	}
+
+	// 出力をリアルタイムでAPIに送信
+	streamer := newOutputStreamer(req.Output, req.TaskID, runner, logger)
+	var wg sync.WaitGroup
+	for _, stream := range []struct {
+		reader io.Reader
+		source string
+		level  string
+	}{
+		{stdoutReader, outputSourceStdout, "INFO"},
+		// 標準エラー出力は進捗の表示にも使われるため、エラーとしては扱わない（送信元で区別する）
+		{stderrReader, outputSourceStderr, "INFO"},
+	} {
+		wg.Add(1)
+		go func(reader io.Reader, source, level string) {
+			defer wg.Done()
+			if err := streamer.Stream(reader, source, level); err != nil {
+				logger.WithError(err).WithField("source", source).Warning("出力の読み取りに失敗しました")
+			}
+		}(stream.reader, stream.source, stream.level)
+	}
+
+	waitErr := cmd.Wait()
+	finishProcess()
+	killMu.Lock()
+	pendingKill := killTimer != nil && killTimer.Stop()
+	killMu.Unlock()
+	// SIGTERMを送信した場合は、リーダーが終了してもSIGTERMを無視する孫プロセス（出力を保持していないもの）が残ることがあるため、
+	// 猶予時間が過ぎてもプロセスグループが残っていればSIGKILLを送信する
+	if pendingKill && !waitProcessGroupExit(cmd, time.Until(killDeadline)) {
+		killGroup()
+	}
+
+	_ = stdoutWriter.Close()
+	_ = stderrWriter.Close()
+	wg.Wait()
+	streamer.Close()
+
+	if errors.Is(waitErr, exec.ErrWaitDelay) && ctx.Err() == nil {
+		// コマンド自体は正常終了したが、バックグラウンドの子プロセスが出力を保持している
+		logger.Warn("コマンド終了後も子プロセスが出力を保持していたため、出力の読み取りを打ち切りました")
+		waitErr = nil
+	}
+	if waitErr != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		waitErr = fmt.Errorf("%w: %v", ErrTimeout, waitErr)
+	}
+
+	if waitErr != nil {
+		if sendErr := req.Output.SendLog(req.TaskID, "ERROR", fmt.Sprintf("[:start-cmd] コマンドが異常終了しました: %v", waitErr)); sendErr != nil {
+			logger.WithError(sendErr).Warning("開始エラーログ送信に失敗しました")
+		}
+		return fmt.Errorf("セッション開始に失敗: %w", waitErr)
+	}
+
+	// セッション終了をAPIにログ送信
+	if sendErr := req.Output.SendLog(req.TaskID, "INFO", "[:start-cmd] コマンド実行完了"); sendErr != nil {
+		logger.WithError(sendErr).Warning("ログ送信に失敗しました")
+	}
+	return nil
+}
+
+// waitProcessGroupExit はプロセスグループのプロセスが全て終了するまで最大timeoutだけ待ち、終了したかどうかを返します
+func waitProcessGroupExit(cmd *exec.Cmd, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for processGroupExists(cmd) {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(processGroupPollInterval)
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ScriptExecutor はスクリプトを一時ファイルに書き出し、インタプリタで実行するランナーです
+// スクリプトのパラメータは KERUTA_PARAM_<名前> 環境変数として渡されます
+type ScriptExecutor struct {
+	Interpreter string
+	Extension   string
+}
+
+// NewShellExecutor は指定したシェル（bash, sh）でスクリプトを実行するランナーを作成します
+func NewShellExecutor(shell string) *ScriptExecutor {
+	return &ScriptExecutor{Interpreter: shell, Extension: ".sh"}
+}
+
+// NewPythonExecutor はpython3でスクリプトを実行するランナーを作成します
+func NewPythonExecutor() *ScriptExecutor {
+	return &ScriptExecutor{Interpreter: "python3", Extension: ".py"}
+}
+
+// Name はランナーの名前を返します
+func (e *ScriptExecutor) Name() string {
+	return e.Interpreter
+}
+
+// Execute は作業ディレクトリでスクリプトを実行します
+func (e *ScriptExecutor) Execute(ctx context.Context, req *Request) error {
+	if strings.TrimSpace(req.Script.Content) == "" {
+		return fmt.Errorf("実行するスクリプトが空です")
+	}
+
+	scriptDir, err := os.MkdirTemp("", "keruta-script-*")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリの作成に失敗: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(scriptDir); err != nil {
+			req.Logger.WithError(err).Warn("一時ディレクトリの削除に失敗しました")
+		}
+	}()
+
+	scriptPath := filepath.Join(scriptDir, e.scriptFilename(req.Script.Filename))
+	if err := os.WriteFile(scriptPath, []byte(req.Script.Content), 0700); err != nil {
+		return fmt.Errorf("スクリプトファイルの作成に失敗: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, e.Interpreter, scriptPath)
+	cmd.Dir = req.WorkDir
+	cmd.Env = append(append(os.Environ(), parameterEnv(req.Script.Parameters)...), req.Env...)
+
+	req.Logger.WithFields(logrus.Fields{
+		"working_dir": req.WorkDir,
+		"command":     cmd.Args,
+		"language":    req.Script.Language,
+	}).Info("🖥️ スクリプトを実行します")
+
+	if err := runCommand(ctx, cmd, req, e.Name()); err != nil {
+		return err
+	}
+
+	req.Logger.Info("✅ スクリプトの実行が完了しました")
+	return nil
+}
+
+// scriptFilename は一時ファイルのファイル名を決定します
+func (e *ScriptExecutor) scriptFilename(filename string) string {
+	name := filepath.Base(filename)
+	if name == "." || name == string(filepath.Separator) || name == "" {
+		return "script" + e.Extension
+	}
+	return name
+}
+
+// parameterEnv はスクリプトのパラメータを環境変数の形式に変換します
+func parameterEnv(parameters map[string]interface{}) []string {
+	env := make([]string, 0, len(parameters))
+	for key, value := range parameters {
+		name := strings.Map(func(r rune) rune {
+			if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			if r >= 'a' && r <= 'z' {
+				return r - 'a' + 'A'
+			}
+			return '_'
+		}, key)
+		env = append(env, fmt.Sprintf("KERUTA_PARAM_%s=%v", name, value))
+	}
+	return env
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"keruta-agent/internal/metrics"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,6 +24,18 @@ type Repository struct {
 	logger         *logrus.Entry
 }
 
+var (
+	// ErrDirtyWorkingTree は未コミットの変更によりブランチを切り替えられないことを表します
+	ErrDirtyWorkingTree = errors.New("未コミットの変更があるためブランチを切り替えられません")
+	// ErrCheckoutConflict は未解決の競合によりブランチを切り替えられないことを表します
+	ErrCheckoutConflict = errors.New("未解決の競合があるためブランチを切り替えられません")
+)
+
+// observeOperation はGit操作の実行時間をメトリクスに記録します
+func observeOperation(operation string, start time.Time, err *error) {
+	metrics.ObserveGitOperation(operation, time.Since(start), *err)
+}
+
 // NewRepository は新しいRepositoryインスタンスを作成します
 func NewRepository(url, ref, path string, logger *logrus.Entry) *Repository {
 	return &Repository{
@@ -85,7 +100,8 @@ func (r *Repository) CloneOrPull() error {
 }
 
 // clone はリポジトリをクローンします
-func (r *Repository) clone() error {
+func (r *Repository) clone() (err error) {
+	defer observeOperation("clone", time.Now(), &err)
 	r.logger.WithFields(logrus.Fields{
 		"url":  r.URL,
 		"ref":  r.Ref,
@@ -128,7 +144,8 @@ func (r *Repository) clone() error {
 }
 
 // pull はリポジトリをプルします
-func (r *Repository) pull() error {
+func (r *Repository) pull() (err error) {
+	defer observeOperation("pull", time.Now(), &err)
 	r.logger.WithFields(logrus.Fields{
 		"url":  r.URL,
 		"ref":  r.Ref,
@@ -220,7 +237,8 @@ func (r *Repository) checkout() error {
 }
 
 // CreateAndCheckoutBranch は新しいブランチを作成してチェックアウトします
-func (r *Repository) CreateAndCheckoutBranch() error {
+func (r *Repository) CreateAndCheckoutBranch() (err error) {
+	defer observeOperation("checkout_branch", time.Now(), &err)
 	if r.NewBranchName == "" {
 		return nil
 	}
@@ -249,8 +267,12 @@ func (r *Repository) CreateAndCheckoutBranch() error {
 		return r.checkoutExistingBranch(r.NewBranchName)
 	}
 
-	// 新しいブランチを作成してチェックアウト
-	cmd := exec.Command("git", "checkout", "-b", r.NewBranchName)
+	// 登録されているrefをベースに新しいブランチを作成してチェックアウト
+	args := []string{"checkout", "-b", r.NewBranchName}
+	if startPoint := r.branchStartPoint(); startPoint != "" {
+		args = append(args, startPoint)
+	}
+	cmd := exec.Command("git", args...)
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
@@ -258,13 +280,54 @@ func (r *Repository) CreateAndCheckoutBranch() error {
 			"branch_name": r.NewBranchName,
 			"output":      string(output),
 		}).Error("新しいブランチの作成・チェックアウトに失敗しました")
-		return fmt.Errorf("git checkout -b %s に失敗: %w\n出力: %s", r.NewBranchName, err, string(output))
+		return checkoutError("git "+strings.Join(args, " "), err, output)
 	}
 
 	r.logger.WithField("branch_name", r.NewBranchName).Info("✅ 新しいブランチを作成・チェックアウトしました")
 	return nil
 }
 
+// branchStartPoint は新しいブランチの起点となるrefを返します
+// リモートのrefを優先し、解決できない場合は空文字（現在のHEAD）を返します
+func (r *Repository) branchStartPoint() string {
+	if r.Ref == "" {
+		return ""
+	}
+
+	for _, candidate := range []string{"origin/" + r.Ref, r.Ref} {
+		cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err := cmd.Run(); err == nil {
+			return candidate
+		}
+	}
+
+	r.logger.WithField("ref", r.Ref).Warn("登録されているrefが見つからないため、現在のHEADからブランチを作成します")
+	return ""
+}
+
+// checkoutError はgit checkoutの失敗を、原因が判別できる場合はErrDirtyWorkingTreeまたはErrCheckoutConflictでラップして返します
+func checkoutError(command string, err error, output []byte) error {
+	if cause := classifyCheckoutOutput(string(output)); cause != nil {
+		return fmt.Errorf("%s に失敗: %w: %w\n出力: %s", command, cause, err, string(output))
+	}
+	return fmt.Errorf("%s に失敗: %w\n出力: %s", command, err, string(output))
+}
+
+// classifyCheckoutOutput はgit checkoutの出力からブランチ切り替えを妨げた原因を判別します
+func classifyCheckoutOutput(output string) error {
+	switch {
+	case strings.Contains(output, "would be overwritten by checkout"),
+		strings.Contains(output, "Please commit your changes or stash them"):
+		return ErrDirtyWorkingTree
+	case strings.Contains(output, "resolve your current index first"),
+		strings.Contains(output, "needs merge"),
+		strings.Contains(output, "you have unmerged files"):
+		return ErrCheckoutConflict
+	default:
+		return nil
+	}
+}
+
 // branchExists はブランチが存在するかどうかを確認します
 func (r *Repository) branchExists(branchName string) bool {
 	// ローカルブランチの存在確認
@@ -294,7 +357,7 @@ func (r *Repository) checkoutExistingBranch(branchName string) error {
 			"branch_name": branchName,
 			"output":      string(output),
 		}).Error("既存ブランチへのチェックアウトに失敗しました")
-		return fmt.Errorf("git checkout %s に失敗: %w\n出力: %s", branchName, err, string(output))
+		return checkoutError("git checkout "+branchName, err, output)
 	}
 
 	r.logger.WithField("branch_name", branchName).Info("既存のブランチにチェックアウトしました")
@@ -302,7 +365,8 @@ func (r *Repository) checkoutExistingBranch(branchName string) error {
 }
 
 // PushBranch は指定されたブランチをリモートにプッシュします
-func (r *Repository) PushBranch(branchName string, force bool) error {
+func (r *Repository) PushBranch(branchName string, force bool) (err error) {
+	defer observeOperation("push", time.Now(), &err)
 	if branchName == "" {
 		return fmt.Errorf("ブランチ名が指定されていません")
 	}
@@ -391,7 +455,8 @@ func (r *Repository) getCurrentBranchName() (string, error) {
 }
 
 // CommitAllChanges は全ての変更をコミットします
-func (r *Repository) CommitAllChanges(message string) error {
+func (r *Repository) CommitAllChanges(message string) (err error) {
+	defer observeOperation("commit", time.Now(), &err)
 	if message == "" {
 		message = "Auto-commit by keruta-agent"
 	}
@@ -559,6 +624,26 @@ func DetermineWorkingDirectory(sessionID string, repositoryURL string) string {
 	return filepath.Join(baseDir, repoName)
 }
 
+// ResolveTemplateDirectory はリポジトリ内のテンプレートパスを作業ディレクトリとして解決します
+// テンプレートパスが空または"."の場合はリポジトリのルートを返します。
+// 絶対パスやリポジトリ外を指すパスはエラーになります
+func ResolveTemplateDirectory(repoDir, templatePath string) (string, error) {
+	if templatePath == "" || templatePath == "." {
+		return repoDir, nil
+	}
+
+	if filepath.IsAbs(templatePath) {
+		return "", fmt.Errorf("テンプレートパスは相対パスで指定してください: %s", templatePath)
+	}
+
+	cleaned := filepath.Clean(templatePath)
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("テンプレートパスがリポジトリ外を指しています: %s", templatePath)
+	}
+
+	return filepath.Join(repoDir, cleaned), nil
+}
+
 // GenerateBranchName はセッションIDやタスクIDに基づいてブランチ名を生成します
 func GenerateBranchName(sessionID, taskID string) string {
 	if sessionID == "" && taskID == "" {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -370,4 +371,58 @@ func TestGetCurrentBranchName(t *testing.T) {
 		// main または master のいずれかであるはず
 		assert.True(t, branchName == "main" || branchName == "master")
 	})
-}
\ No newline at end of file
+}
+func TestCreateAndCheckoutBranchDirtyWorkingTree(t *testing.T) {
+	if !isGitAvailable() {
+		t.Skip("Git command not available")
+	}
+
+	gitDir := t.TempDir()
+	require.NoError(t, runGitCommand(gitDir, "init"))
+	require.NoError(t, runGitCommand(gitDir, "config", "user.name", "Test User"))
+	require.NoError(t, runGitCommand(gitDir, "config", "user.email", "test@example.com"))
+
+	testFile := filepath.Join(gitDir, "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("base"), 0644))
+	require.NoError(t, runGitCommand(gitDir, "add", "test.txt"))
+	require.NoError(t, runGitCommand(gitDir, "commit", "-m", "Initial commit"))
+
+	baseBranch, err := runGitCommandWithOutput(gitDir, "rev-parse", "--abbrev-ref", "HEAD")
+	require.NoError(t, err)
+	baseRef := strings.TrimSpace(string(baseBranch))
+
+	// 別の内容を持つ既存ブランチを作成
+	require.NoError(t, runGitCommand(gitDir, "checkout", "-b", "keruta-task-existing"))
+	require.NoError(t, os.WriteFile(testFile, []byte("existing"), 0644))
+	require.NoError(t, runGitCommand(gitDir, "commit", "-am", "Existing branch"))
+	require.NoError(t, runGitCommand(gitDir, "checkout", baseRef))
+
+	logger := logrus.NewEntry(logrus.New())
+	logger.Logger.SetLevel(logrus.ErrorLevel)
+
+	t.Run("登録されたrefから新しいブランチを作成する", func(t *testing.T) {
+		require.NoError(t, runGitCommand(gitDir, "checkout", "keruta-task-existing"))
+
+		repo := NewRepositoryWithBranch("", baseRef, gitDir, "keruta-task-new", logger)
+		require.NoError(t, repo.CreateAndCheckoutBranch())
+
+		content, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		assert.Equal(t, "base", string(content))
+	})
+
+	t.Run("未コミットの変更でチェックアウトできない場合はErrDirtyWorkingTree", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(testFile, []byte("dirty"), 0644))
+
+		repo := NewRepositoryWithBranch("", baseRef, gitDir, "keruta-task-existing", logger)
+		err := repo.CreateAndCheckoutBranch()
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrDirtyWorkingTree)
+	})
+}
+
+func TestClassifyCheckoutOutput(t *testing.T) {
+	assert.ErrorIs(t, classifyCheckoutOutput("error: Your local changes to the following files would be overwritten by checkout:\n\ttest.txt"), ErrDirtyWorkingTree)
+	assert.ErrorIs(t, classifyCheckoutOutput("error: you need to resolve your current index first"), ErrCheckoutConflict)
+	assert.NoError(t, classifyCheckoutOutput("fatal: not a git repository"))
+}
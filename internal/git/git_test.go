@@ -208,3 +208,26 @@ func TestDetermineWorkingDirectoryRepoNameExtraction(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveTemplateDirectory(t *testing.T) {
+	repoDir := filepath.Join("/work", "repo")
+
+	dir, err := ResolveTemplateDirectory(repoDir, "")
+	assert.NoError(t, err)
+	assert.Equal(t, repoDir, dir)
+
+	dir, err = ResolveTemplateDirectory(repoDir, ".")
+	assert.NoError(t, err)
+	assert.Equal(t, repoDir, dir)
+
+	dir, err = ResolveTemplateDirectory(repoDir, "templates/web/")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(repoDir, "templates", "web"), dir)
+
+	// リポジトリ外を指すパスは拒否する
+	_, err = ResolveTemplateDirectory(repoDir, "../other")
+	assert.Error(t, err)
+
+	_, err = ResolveTemplateDirectory(repoDir, "/etc")
+	assert.Error(t, err)
+}
@@ -1,10 +1,11 @@
 package logger
 
 import (
+	"context"
 	"os"
-	"strings"
 
 	"keruta-agent/internal/config"
+	"keruta-agent/internal/redact"
 
 	"github.com/sirupsen/logrus"
 )
@@ -15,13 +16,24 @@ type LogSender interface {
 }
 
 // APILogHook はAPIにログを送信するためのHookです
+// ログはShipperのリングバッファに追加され、バックグラウンドでまとめて送信されます
 type APILogHook struct {
-	client LogSender
+	client  LogSender
+	shipper *Shipper
 }
 
-// NewAPILogHook は新しいAPILogHookを作成します
+// NewAPILogHook はデフォルト設定の新しいAPILogHookを作成します
 func NewAPILogHook(client LogSender) *APILogHook {
-	return &APILogHook{client: client}
+	return NewAPILogHookWithOptions(client, ShipperOptions{})
+}
+
+// NewAPILogHookWithOptions は指定したShipperの設定で新しいAPILogHookを作成します
+func NewAPILogHookWithOptions(client LogSender, opts ShipperOptions) *APILogHook {
+	hook := &APILogHook{client: client}
+	if client != nil {
+		hook.shipper = NewShipper(client, opts)
+	}
+	return hook
 }
 
 // Levels はHookが処理するログレベルを返します
@@ -35,14 +47,14 @@ func (hook *APILogHook) Levels() []logrus.Level {
 	}
 }
 
-// Fire はログエントリーをAPIに送信します
+// Fire はログエントリーを送信待ちのバッファに追加します
 func (hook *APILogHook) Fire(entry *logrus.Entry) error {
-	if hook.client == nil {
+	if hook.shipper == nil {
 		return nil
 	}
 
 	// API関連のログは送信しない（無限ループ防止）
-	if component, ok := entry.Data["component"]; ok && component == "api" {
+	if component, ok := entry.Data["component"]; ok && (component == "api" || component == "outbox") {
 		return nil
 	}
 
@@ -52,15 +64,24 @@ func (hook *APILogHook) Fire(entry *logrus.Entry) error {
 		return nil // タスクIDが設定されていない場合は送信しない
 	}
 
-	// ログレベルを文字列に変換
-	level := strings.ToUpper(entry.Level.String())
+	hook.shipper.Enqueue(newRecord(taskID, entry))
+	return nil
+}
 
-	// APIにログを送信（エラーは無視）
-	go func() {
-		_ = hook.client.SendLog(taskID, level, entry.Message)
-	}()
+// Flush はバッファ内のログをすべて送信し終えるか、ctxが終了するまで待機します
+func (hook *APILogHook) Flush(ctx context.Context) error {
+	if hook.shipper == nil {
+		return nil
+	}
+	return hook.shipper.Flush(ctx)
+}
 
-	return nil
+// Close はバッファ内のログを送信してから送信用のゴルーチンを停止します
+func (hook *APILogHook) Close(ctx context.Context) error {
+	if hook.shipper == nil {
+		return nil
+	}
+	return hook.shipper.Close(ctx)
 }
 
 var apiLogHook *APILogHook
@@ -69,12 +90,28 @@ var apiLogHook *APILogHook
 func SetAPIClient(client LogSender) {
 	if apiLogHook != nil {
 		logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+		// 以前のHookに残っているログは送信を試みてから停止する
+		ctx, cancel := context.WithTimeout(context.Background(), defaultFlushInterval)
+		_ = apiLogHook.Close(ctx)
+		cancel()
 	}
-	
-	apiLogHook = NewAPILogHook(client)
+
+	apiLogHook = NewAPILogHookWithOptions(client, ShipperOptions{
+		BufferSize: config.GetLogBufferSize(),
+		Policy:     ParseOverflowPolicy(config.GetLogOverflowPolicy()),
+	})
 	logrus.AddHook(apiLogHook)
 }
 
+// Flush はAPIへの送信を待っているログをすべて送信し終えるか、ctxが終了するまで待機します
+// グレースフルシャットダウン時に呼び出してください
+func Flush(ctx context.Context) error {
+	if apiLogHook == nil {
+		return nil
+	}
+	return apiLogHook.Flush(ctx)
+}
+
 // Init はロガーを初期化します
 func Init() error {
 	// ログレベルの設定
@@ -84,14 +121,25 @@ func Init() error {
 	}
 	logrus.SetLevel(level)
 
+	// 秘匿情報のマスク設定（APIトークンと、名前がパターンに一致する環境変数の値をマスクする）
+	envPatterns := config.GetRedactEnvPatterns()
+	if envPatterns == nil {
+		envPatterns = redact.DefaultEnvPatterns
+	}
+	if err := redact.Configure([]string{config.GlobalConfig.API.Token}, envPatterns, config.GetRedactPatterns()); err != nil {
+		return err
+	}
+
 	// ログフォーマットの設定
+	var formatter logrus.Formatter
 	if config.GlobalConfig.Logging.Format == "json" {
-		logrus.SetFormatter(&logrus.JSONFormatter{})
+		formatter = &logrus.JSONFormatter{}
 	} else {
-		logrus.SetFormatter(&logrus.TextFormatter{
+		formatter = &logrus.TextFormatter{
 			FullTimestamp: true,
-		})
+		}
 	}
+	logrus.SetFormatter(redactingFormatter{formatter})
 
 	// 出力先の設定
 	logrus.SetOutput(os.Stdout)
@@ -99,6 +147,20 @@ func Init() error {
 	return nil
 }
 
+// redactingFormatter は出力する前にログから秘匿情報をマスクするFormatterです
+type redactingFormatter struct {
+	logrus.Formatter
+}
+
+// Format はログを整形し、秘匿情報をマスクします
+func (f redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return redact.Bytes(data), nil
+}
+
 // WithTaskID はタスクID付きのログエントリを作成します
 func WithTaskID() *logrus.Entry {
 	return logrus.WithField("task_id", config.GetTaskID())
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	sequenceMu sync.Mutex
+	// lastSequence はこのプロセスでこれまでに払い出した最大の番号です
+	lastSequence uint64
+)
+
+// NextSequence はログのシーケンス番号を返します
+// 番号は現在時刻（ナノ秒）と前回の番号+1の大きい方で、サーバー側でこの番号順に並べ替えられます
+// デーモンと`keruta log`など別のプロセスから同じタスクのログを送信しても、番号は記録した時刻の順に並びます
+// プロセス間の順序は時計に依存するため、ほぼ同時（1ナノ秒の単位や時計の精度以下）に記録したログや、
+// 時計が巻き戻った場合の順序は保証されません。同じプロセスで払い出す番号は常に増加します
+func NextSequence() uint64 {
+	sequenceMu.Lock()
+	defer sequenceMu.Unlock()
+
+	seq := uint64(time.Now().UnixNano())
+	if seq <= lastSequence {
+		seq = lastSequence + 1
+	}
+	lastSequence = seq
+	return seq
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextSequenceIsMonotonic(t *testing.T) {
+	const workers, perWorker = 8, 100
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for j := 0; j < perWorker; j++ {
+				seq := NextSequence()
+				assert.Greater(t, seq, last)
+				last = seq
+				mu.Lock()
+				seen[seq] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	// 複数のゴルーチンから呼び出しても番号は重複しない
+	assert.Len(t, seen, workers*perWorker)
+
+	// 別のプロセスが後から払い出す番号も大きくなるよう、番号は現在時刻以上になる
+	before := uint64(time.Now().UnixNano())
+	assert.GreaterOrEqual(t, NextSequence(), before)
+}
+
+func TestNewRecordCarriesSourceAndFields(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"component": "git",
+		"branch":    "main",
+		"elapsed":   1500 * time.Millisecond,
+	}).WithError(errors.New("push failed"))
+	entry.Level = logrus.WarnLevel
+	entry.Message = "push"
+	entry.Time = time.Now()
+
+	first := newRecord("record-task", entry)
+	second := newRecord("record-task", entry)
+
+	assert.Equal(t, "WARNING", first.Level)
+	assert.Equal(t, sourceGit, first.Source)
+	assert.Greater(t, second.Sequence, first.Sequence)
+	require.NotNil(t, first.Fields)
+	assert.Equal(t, "main", first.Fields["branch"])
+	assert.Equal(t, "1.5s", first.Fields["elapsed"])
+	assert.Equal(t, "push failed", first.Fields[logrus.ErrorKey])
+}
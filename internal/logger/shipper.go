@@ -0,0 +1,390 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"keruta-agent/internal/metrics"
+	"keruta-agent/internal/redact"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBufferSize はAPIへの送信を待つログを保持するリングバッファのデフォルトサイズです
+	DefaultBufferSize = 1000
+	// defaultBatchSize は1回のAPI呼び出しで送信するログの最大件数です
+	defaultBatchSize = 100
+	// defaultFlushInterval はバッチが満杯でなくても送信する間隔です
+	defaultFlushInterval = time.Second
+	// defaultSampleRate はサンプリング時に残すログの割合（N件に1件）です
+	defaultSampleRate = 10
+)
+
+// OverflowPolicy はバッファが満杯のときの扱いを表します
+type OverflowPolicy string
+
+const (
+	// DropOldest は最も古いログを破棄して新しいログを保持します
+	DropOldest OverflowPolicy = "drop_oldest"
+	// DropNewest は新しいログを破棄します
+	DropNewest OverflowPolicy = "drop_newest"
+	// Sample は新しいログをN件に1件だけ保持します（WARN以上のログは常に保持します）
+	Sample OverflowPolicy = "sample"
+)
+
+// ParseOverflowPolicy は文字列をOverflowPolicyに変換します。不明な値の場合はDropOldestを返します
+func ParseOverflowPolicy(value string) OverflowPolicy {
+	switch policy := OverflowPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case DropOldest, DropNewest, Sample:
+		return policy
+	default:
+		return DropOldest
+	}
+}
+
+// ログの送信元を表します（api.LogSource*と同じ値です）
+const (
+	sourceAgent = "agent"
+	sourceGit   = "git"
+)
+
+// ログを破棄した理由を表します（メトリクスのラベルに使用します）
+const (
+	dropReasonOverflow   = "overflow"
+	dropReasonSendFailed = "send_failed"
+)
+
+// Record はAPIに送信するログ1件を表します
+type Record struct {
+	TaskID   string
+	Level    string
+	Message  string
+	Time     time.Time
+	Sequence uint64
+	Source   string
+	Fields   map[string]interface{}
+}
+
+// BatchLogSender は複数のログをまとめて送信できるLogSenderです
+type BatchLogSender interface {
+	LogSender
+	SendLogRecords(taskID string, records []Record) error
+}
+
+// ShipperOptions はShipperの設定です。ゼロ値の項目にはデフォルト値が使われます
+type ShipperOptions struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Policy        OverflowPolicy
+	SampleRate    int
+}
+
+// Shipper はログをリングバッファに溜め、バックグラウンドでまとめてAPIに送信します
+// 送信は1つのゴルーチンで行うため、ログは記録された順番で届きます
+type Shipper struct {
+	client LogSender
+	opts   ShipperOptions
+
+	mu       sync.Mutex
+	buf      []Record
+	head     int
+	count    int
+	dropped  int
+	sampled  int
+	inflight bool
+	idle     *sync.Cond
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewShipper はShipperを作成し、送信用のゴルーチンを開始します
+func NewShipper(client LogSender, opts ShipperOptions) *Shipper {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.Policy == "" {
+		opts.Policy = DropOldest
+	}
+	if opts.SampleRate <= 0 {
+		opts.SampleRate = defaultSampleRate
+	}
+
+	s := &Shipper{
+		client: client,
+		opts:   opts,
+		buf:    make([]Record, opts.BufferSize),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.idle = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+// Enqueue はログをバッファに追加します。ブロックせず、バッファが満杯の場合はポリシーに従って破棄します
+func (s *Shipper) Enqueue(record Record) {
+	s.mu.Lock()
+	if s.count == len(s.buf) {
+		metrics.AddLogsDropped(dropReasonOverflow, 1)
+		if !s.makeRoom(record) {
+			s.dropped++
+			s.mu.Unlock()
+			return
+		}
+	}
+	s.buf[(s.head+s.count)%len(s.buf)] = record
+	s.count++
+	metrics.SetLogQueueDepth(s.count)
+	full := s.count >= s.opts.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		s.notify()
+	}
+}
+
+// makeRoom はバッファが満杯のときにポリシーに従って空きを作ります
+// recordを破棄すべき場合はfalseを返します
+func (s *Shipper) makeRoom(record Record) bool {
+	switch s.opts.Policy {
+	case DropNewest:
+		return false
+	case Sample:
+		if !isImportant(record.Level) {
+			s.sampled++
+			if s.sampled%s.opts.SampleRate != 0 {
+				return false
+			}
+		}
+	}
+	// 最も古いログを破棄する
+	s.head = (s.head + 1) % len(s.buf)
+	s.count--
+	s.dropped++
+	return true
+}
+
+// isImportant はサンプリングの対象外とするログレベルかどうかを返します
+func isImportant(level string) bool {
+	switch level {
+	case "WARNING", "WARN", "ERROR", "FATAL", "PANIC":
+		return true
+	default:
+		return false
+	}
+}
+
+// Dropped はバッファが満杯のため破棄したログの件数を返します（まだ報告していない分）
+func (s *Shipper) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Flush はバッファ内のログをすべて送信し終えるか、ctxが終了するまで待機します
+func (s *Shipper) Flush(ctx context.Context) error {
+	s.notify()
+
+	flushed := make(chan struct{})
+	go func() {
+		s.mu.Lock()
+		for (s.count > 0 || s.inflight) && ctx.Err() == nil {
+			s.idle.Wait()
+		}
+		s.mu.Unlock()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		// 待機中のゴルーチンを起こして終了させる
+		s.mu.Lock()
+		remaining := s.count
+		s.idle.Broadcast()
+		s.mu.Unlock()
+		return fmt.Errorf("ログの送信が完了しませんでした（残り%d件）: %w", remaining, ctx.Err())
+	}
+}
+
+// Close はバッファ内のログを送信してから送信用のゴルーチンを停止します
+// 送信中のAPI呼び出しが終わらない場合も、ctxの期限が切れた時点で待たずに戻ります
+func (s *Shipper) Close(ctx context.Context) error {
+	err := s.Flush(ctx)
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		if err == nil {
+			err = fmt.Errorf("ログの送信用のゴルーチンが停止しませんでした: %w", ctx.Err())
+		}
+	}
+	return err
+}
+
+func (s *Shipper) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run はバッファのログを定期的、またはバッチが満杯になったときに送信します
+func (s *Shipper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		for s.shipBatch() {
+		}
+	}
+}
+
+// shipBatch はバッファの先頭から最大BatchSize件を送信します。送信するログがなかった場合はfalseを返します
+func (s *Shipper) shipBatch() bool {
+	s.mu.Lock()
+	n := s.count
+	if n > s.opts.BatchSize {
+		n = s.opts.BatchSize
+	}
+	if n == 0 && s.dropped == 0 {
+		s.idle.Broadcast()
+		s.mu.Unlock()
+		return false
+	}
+	batch := make([]Record, 0, n+1)
+	if s.dropped > 0 {
+		// 破棄したログがあったことをサーバー側でも分かるようにする
+		taskID := s.droppedTaskID()
+		batch = append(batch, Record{
+			TaskID:   taskID,
+			Level:    "WARN",
+			Message:  fmt.Sprintf("ログの送信が追いつかないため、%d件のログを破棄しました", s.dropped),
+			Time:     time.Now(),
+			Sequence: NextSequence(),
+			Source:   sourceAgent,
+			Fields:   map[string]interface{}{"dropped": s.dropped},
+		})
+		s.dropped = 0
+	}
+	for i := 0; i < n; i++ {
+		batch = append(batch, s.buf[s.head])
+		s.buf[s.head] = Record{}
+		s.head = (s.head + 1) % len(s.buf)
+	}
+	s.count -= n
+	metrics.SetLogQueueDepth(s.count)
+	s.inflight = true
+	s.mu.Unlock()
+
+	s.send(batch)
+
+	s.mu.Lock()
+	s.inflight = false
+	s.idle.Broadcast()
+	s.mu.Unlock()
+	return true
+}
+
+// droppedTaskID は破棄の通知を送るタスクIDを返します（バッファ先頭のログのタスク）
+func (s *Shipper) droppedTaskID() string {
+	if s.count > 0 {
+		return s.buf[s.head].TaskID
+	}
+	return ""
+}
+
+// send はタスクごとに連続するログをまとめて送信します。送信に失敗したログは破棄し、件数をメトリクスに記録します
+func (s *Shipper) send(batch []Record) {
+	for start := 0; start < len(batch); {
+		end := start + 1
+		for end < len(batch) && batch[end].TaskID == batch[start].TaskID {
+			end++
+		}
+		group := batch[start:end]
+		start = end
+
+		taskID := group[0].TaskID
+		if taskID == "" {
+			continue
+		}
+		if sender, ok := s.client.(BatchLogSender); ok {
+			if err := sender.SendLogRecords(taskID, group); err != nil {
+				metrics.AddLogsDropped(dropReasonSendFailed, len(group))
+			}
+			continue
+		}
+		for _, record := range group {
+			if err := s.client.SendLog(taskID, record.Level, record.Message); err != nil {
+				metrics.AddLogsDropped(dropReasonSendFailed, 1)
+			}
+		}
+	}
+}
+
+// newRecord はlogrusのエントリーから送信用のログを作成します
+// シーケンス番号はバッファに追加する前に付与するため、記録された順番を表します
+// メッセージとフィールドに含まれる秘匿情報はマスクします
+func newRecord(taskID string, entry *logrus.Entry) Record {
+	source := sourceAgent
+	if component, ok := entry.Data["component"]; ok && component == "git" {
+		source = sourceGit
+	}
+	return Record{
+		TaskID:   taskID,
+		Level:    strings.ToUpper(entry.Level.String()),
+		Message:  redact.String(entry.Message),
+		Time:     entry.Time,
+		Sequence: NextSequence(),
+		Source:   source,
+		Fields:   recordFields(entry.Data),
+	}
+}
+
+// recordFields はlogrusのフィールドをJSONにエンコードできる値に変換します
+func recordFields(data logrus.Fields) map[string]interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	redactor := redact.Default()
+	fields := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		switch v := redactor.Value(value).(type) {
+		case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fields[key] = v
+		case error:
+			fields[key] = v.Error()
+		case time.Duration:
+			fields[key] = v.String()
+		case time.Time:
+			fields[key] = v.Format(time.RFC3339Nano)
+		case fmt.Stringer:
+			fields[key] = v.String()
+		default:
+			fields[key] = fmt.Sprint(v)
+		}
+	}
+	return fields
+}
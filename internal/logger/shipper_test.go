@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSender は送信されたログを記録するテスト用のBatchLogSenderです
+type recordingSender struct {
+	mu      sync.Mutex
+	batches [][]Record
+	block   chan struct{}
+}
+
+func (r *recordingSender) SendLog(taskID string, level string, message string) error {
+	return r.SendLogRecords(taskID, []Record{{TaskID: taskID, Level: level, Message: message}})
+}
+
+func (r *recordingSender) SendLogRecords(taskID string, records []Record) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]Record(nil), records...))
+	return nil
+}
+
+func (r *recordingSender) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []string
+	for _, batch := range r.batches {
+		for _, record := range batch {
+			messages = append(messages, record.Message)
+		}
+	}
+	return messages
+}
+
+func enqueueMessages(s *Shipper, level string, messages ...string) {
+	for _, message := range messages {
+		s.Enqueue(Record{TaskID: "task-1", Level: level, Message: message})
+	}
+}
+
+func TestShipperBatchesInOrder(t *testing.T) {
+	sender := &recordingSender{}
+	shipper := NewShipper(sender, ShipperOptions{BatchSize: 3, FlushInterval: time.Hour})
+	defer shipper.Close(context.Background())
+
+	var expected []string
+	for i := 0; i < 10; i++ {
+		message := fmt.Sprintf("line %d", i)
+		expected = append(expected, message)
+		enqueueMessages(shipper, "INFO", message)
+	}
+
+	require.NoError(t, shipper.Flush(context.Background()))
+	assert.Equal(t, expected, sender.messages())
+	for _, batch := range sender.batches {
+		assert.LessOrEqual(t, len(batch), 3)
+	}
+}
+
+func TestShipperGroupsByTask(t *testing.T) {
+	sender := &recordingSender{}
+	shipper := NewShipper(sender, ShipperOptions{FlushInterval: time.Hour})
+	defer shipper.Close(context.Background())
+
+	shipper.Enqueue(Record{TaskID: "task-1", Message: "a"})
+	shipper.Enqueue(Record{TaskID: "task-2", Message: "b"})
+	shipper.Enqueue(Record{TaskID: "task-1", Message: "c"})
+
+	require.NoError(t, shipper.Flush(context.Background()))
+	require.Len(t, sender.batches, 3)
+	assert.Equal(t, []string{"a", "b", "c"}, sender.messages())
+}
+
+func TestShipperOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   OverflowPolicy
+		expected []string
+	}{
+		{"drop_oldest", DropOldest, []string{"3", "4"}},
+		{"drop_newest", DropNewest, []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &recordingSender{}
+			shipper := NewShipper(sender, ShipperOptions{BufferSize: 2, BatchSize: 10, FlushInterval: time.Hour, Policy: tt.policy})
+			defer shipper.Close(context.Background())
+
+			enqueueMessages(shipper, "INFO", "1", "2", "3", "4")
+			assert.Equal(t, 2, shipper.Dropped())
+
+			require.NoError(t, shipper.Flush(context.Background()))
+			messages := sender.messages()
+			require.Len(t, messages, 3)
+			// 破棄した件数を先頭で通知する
+			assert.Contains(t, messages[0], "2件のログを破棄しました")
+			assert.Equal(t, tt.expected, messages[1:])
+		})
+	}
+}
+
+func TestShipperSampleKeepsImportantLogs(t *testing.T) {
+	sender := &recordingSender{}
+	shipper := NewShipper(sender, ShipperOptions{BufferSize: 2, BatchSize: 10, FlushInterval: time.Hour, Policy: Sample, SampleRate: 2})
+	defer shipper.Close(context.Background())
+
+	enqueueMessages(shipper, "INFO", "1", "2", "3", "4")
+	enqueueMessages(shipper, "ERROR", "error")
+
+	require.NoError(t, shipper.Flush(context.Background()))
+	messages := sender.messages()
+	require.Len(t, messages, 3)
+	assert.Equal(t, []string{"4", "error"}, messages[1:])
+}
+
+func TestShipperFlushTimeout(t *testing.T) {
+	sender := &recordingSender{block: make(chan struct{})}
+	shipper := NewShipper(sender, ShipperOptions{FlushInterval: time.Hour})
+
+	enqueueMessages(shipper, "INFO", "stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, shipper.Flush(ctx))
+
+	close(sender.block)
+	require.NoError(t, shipper.Close(context.Background()))
+	assert.Equal(t, []string{"stuck"}, sender.messages())
+}
+
+func TestShipperCloseTimeout(t *testing.T) {
+	sender := &recordingSender{block: make(chan struct{})}
+	defer close(sender.block)
+	shipper := NewShipper(sender, ShipperOptions{FlushInterval: time.Hour})
+
+	enqueueMessages(shipper, "INFO", "stuck")
+
+	// 送信が終わらなくても、期限が切れたらCloseは戻る
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() { closed <- shipper.Close(ctx) }()
+
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after the context expired")
+	}
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	assert.Equal(t, DropNewest, ParseOverflowPolicy("DROP_NEWEST"))
+	assert.Equal(t, Sample, ParseOverflowPolicy(" sample "))
+	assert.Equal(t, DropOldest, ParseOverflowPolicy("unknown"))
+}
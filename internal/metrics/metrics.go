@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "keruta"
+
+// タスクの実行結果
+const (
+	TaskSucceeded = "succeeded"
+	TaskFailed    = "failed"
+	TaskCancelled = "cancelled"
+)
+
+// unknownErrorCode はエラーコードなしで失敗したタスクに付けるエラーコードです
+const unknownErrorCode = "UNKNOWN"
+
+// registry はエージェントのメトリクスを登録するレジストリです
+// テストや複数のデーモンで重複登録にならないよう、デフォルトのレジストリは使用しません
+var registry = prometheus.NewRegistry()
+
+var (
+	tasksExecuted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_executed_total",
+		Help:      "実行したタスクの数",
+	})
+	tasksSucceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_succeeded_total",
+		Help:      "成功したタスクの数",
+	})
+	tasksFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_failed_total",
+		Help:      "失敗したタスクの数（エラーコード別）",
+	}, []string{"error_code"})
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "タスクの実行時間（実行結果別）",
+		Buckets:   []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	}, []string{"result"})
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "keruta APIの呼び出し時間（エンドポイント・ステータスコード別）",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "endpoint", "code"})
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_errors_total",
+		Help:      "keruta APIの呼び出しに失敗した回数（エンドポイント別）",
+	}, []string{"method", "endpoint", "code"})
+
+	logQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "log_queue_depth",
+		Help:      "APIへの送信を待っているログの件数",
+	})
+	logsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logs_dropped_total",
+		Help:      "APIに送信せずに破棄したログの件数（理由別）",
+	}, []string{"reason"})
+
+	gitOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "git_operation_duration_seconds",
+		Help:      "Git操作の実行時間（操作・実行結果別）",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"operation", "result"})
+
+	processCPU = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "executor_process_cpu_seconds",
+		Help:      "実行中のタスクのプロセス（claudeなど）が使用したCPU時間",
+	}, []string{"runner"})
+	processRSS = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "executor_process_resident_memory_bytes",
+		Help:      "実行中のタスクのプロセス（claudeなど）の常駐メモリサイズ",
+	}, []string{"runner"})
+	processCPUTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "executor_cpu_seconds_total",
+		Help:      "終了したタスクのプロセスが使用したCPU時間の合計",
+	}, []string{"runner"})
+)
+
+func init() {
+	registry.MustRegister(
+		tasksExecuted,
+		tasksSucceeded,
+		tasksFailed,
+		taskDuration,
+		apiRequestDuration,
+		apiErrors,
+		logQueueDepth,
+		logsDropped,
+		gitOperationDuration,
+		processCPU,
+		processRSS,
+		processCPUTotal,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler はメトリクスをPrometheusのテキスト形式で返すハンドラーを返します
+// extraにはリクエストごとに値を計算するメトリクス（デーモンの状態など）を指定します
+func Handler(extra ...prometheus.Collector) http.Handler {
+	if len(extra) == 0 {
+		return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	}
+	extraRegistry := prometheus.NewRegistry()
+	extraRegistry.MustRegister(extra...)
+	return promhttp.HandlerFor(prometheus.Gatherers{registry, extraRegistry}, promhttp.HandlerOpts{})
+}
+
+// ObserveTask はタスクの実行結果と実行時間を記録します
+func ObserveTask(result, errorCode string, duration time.Duration) {
+	tasksExecuted.Inc()
+	switch result {
+	case TaskSucceeded:
+		tasksSucceeded.Inc()
+	case TaskFailed:
+		if errorCode == "" {
+			errorCode = unknownErrorCode
+		}
+		tasksFailed.WithLabelValues(errorCode).Inc()
+	}
+	taskDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// ObserveAPIRequest はAPI呼び出しの所要時間を記録します
+// statusCodeが0の場合は接続エラーとして扱います
+func ObserveAPIRequest(method, path string, statusCode int, duration time.Duration) {
+	endpoint := Endpoint(path)
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	apiRequestDuration.WithLabelValues(method, endpoint, code).Observe(duration.Seconds())
+	if statusCode == 0 || statusCode >= 400 {
+		apiErrors.WithLabelValues(method, endpoint, code).Inc()
+	}
+}
+
+// SetLogQueueDepth は送信待ちのログの件数を記録します
+func SetLogQueueDepth(depth int) {
+	logQueueDepth.Set(float64(depth))
+}
+
+// AddLogsDropped は破棄したログの件数を記録します
+func AddLogsDropped(reason string, count int) {
+	logsDropped.WithLabelValues(reason).Add(float64(count))
+}
+
+// ObserveGitOperation はGit操作の実行時間を記録します
+func ObserveGitOperation(operation string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	gitOperationDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// SetProcessUsage は実行中のタスクのプロセスのCPU時間と常駐メモリサイズを記録します
+func SetProcessUsage(runner string, cpuSeconds float64, rssBytes uint64) {
+	processCPU.WithLabelValues(runner).Set(cpuSeconds)
+	processRSS.WithLabelValues(runner).Set(float64(rssBytes))
+}
+
+// FinishProcess はタスクのプロセスの終了時に使用したCPU時間を記録し、実行中の値をリセットします
+func FinishProcess(runner string, cpuSeconds float64) {
+	processCPUTotal.WithLabelValues(runner).Add(cpuSeconds)
+	processCPU.WithLabelValues(runner).Set(0)
+	processRSS.WithLabelValues(runner).Set(0)
+}
+
+// idCollections はパスの次の要素がIDになるコレクション名です
+var idCollections = map[string]bool{
+	"tasks":      true,
+	"sessions":   true,
+	"workspaces": true,
+	"artifacts":  true,
+	"uploads":    true,
+}
+
+// reservedSegments はコレクション名の後に続いてもIDではないパスの要素です
+var reservedSegments = map[string]bool{
+	"pending": true,
+	"search":  true,
+	"uploads": true,
+}
+
+// Endpoint はAPIのパスからクエリ文字列とIDを取り除き、メトリクスのラベルに使うエンドポイント名を返します
+// 例: /api/v1/tasks/abc-123/status?x=1 → /api/v1/tasks/{id}/status
+func Endpoint(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i] != "" && idCollections[segments[i-1]] && !reservedSegments[segments[i]] {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/tasks/task-123/status", "/api/v1/tasks/{id}/status"},
+		{"/api/v1/tasks/task-123", "/api/v1/tasks/{id}"},
+		{"/api/v1/sessions/abc/tasks?status=PENDING", "/api/v1/sessions/{id}/tasks"},
+		{"/api/v1/workspaces/ws-1/tasks/pending", "/api/v1/workspaces/{id}/tasks/pending"},
+		{"/api/v1/sessions/search/partial-id?partialId=abc", "/api/v1/sessions/search/partial-id"},
+		{"/api/v1/tasks/t1/artifacts/uploads/up-1/complete", "/api/v1/tasks/{id}/artifacts/uploads/{id}/complete"},
+		{"/api/v1/tasks/t1/artifacts/a1", "/api/v1/tasks/{id}/artifacts/{id}"},
+		{"/health", "/health"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Endpoint(tt.path), tt.path)
+	}
+}
+
+func TestObserveTask(t *testing.T) {
+	executed := testutil.ToFloat64(tasksExecuted)
+	succeeded := testutil.ToFloat64(tasksSucceeded)
+	timeouts := testutil.ToFloat64(tasksFailed.WithLabelValues("TIMEOUT"))
+	unknown := testutil.ToFloat64(tasksFailed.WithLabelValues(unknownErrorCode))
+
+	ObserveTask(TaskSucceeded, "", time.Minute)
+	ObserveTask(TaskFailed, "TIMEOUT", time.Hour)
+	ObserveTask(TaskFailed, "", time.Second)
+	ObserveTask(TaskCancelled, "", time.Second)
+
+	assert.Equal(t, executed+4, testutil.ToFloat64(tasksExecuted))
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(tasksSucceeded))
+	assert.Equal(t, timeouts+1, testutil.ToFloat64(tasksFailed.WithLabelValues("TIMEOUT")))
+	assert.Equal(t, unknown+1, testutil.ToFloat64(tasksFailed.WithLabelValues(unknownErrorCode)))
+}
+
+func TestObserveAPIRequest(t *testing.T) {
+	errorsOf := func(code string) float64 {
+		return testutil.ToFloat64(apiErrors.WithLabelValues("GET", "/api/v1/tasks/{id}", code))
+	}
+	serverErrors, networkErrors := errorsOf("503"), errorsOf("error")
+
+	ObserveAPIRequest("GET", "/api/v1/tasks/task-1", 200, 10*time.Millisecond)
+	ObserveAPIRequest("GET", "/api/v1/tasks/task-2", 503, 10*time.Millisecond)
+	ObserveAPIRequest("GET", "/api/v1/tasks/task-3", 0, 10*time.Millisecond)
+
+	assert.Equal(t, serverErrors+1, errorsOf("503"))
+	assert.Equal(t, networkErrors+1, errorsOf("error"))
+	assert.Equal(t, 0.0, errorsOf("200"))
+}
+
+func TestHandler(t *testing.T) {
+	SetLogQueueDepth(7)
+	AddLogsDropped("overflow", 2)
+	ObserveGitOperation("push", 2*time.Second, errors.New("rejected"))
+	SetProcessUsage("claude", 1.5, 4096)
+
+	extra := prometheus.NewGauge(prometheus.GaugeOpts{Name: "keruta_test_extra", Help: "テスト用"})
+	extra.Set(3)
+
+	w := httptest.NewRecorder()
+	Handler(extra).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	assert.Contains(t, text, "keruta_log_queue_depth 7")
+	assert.Contains(t, text, `keruta_logs_dropped_total{reason="overflow"}`)
+	assert.Contains(t, text, `keruta_git_operation_duration_seconds_count{operation="push",result="failure"} 1`)
+	assert.Contains(t, text, `keruta_executor_process_resident_memory_bytes{runner="claude"} 4096`)
+	assert.Contains(t, text, "keruta_test_extra 3")
+
+	FinishProcess("claude", 2)
+	assert.Equal(t, 0.0, testutil.ToFloat64(processRSS.WithLabelValues("claude")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(processCPUTotal.WithLabelValues("claude")))
+}
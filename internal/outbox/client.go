@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+// replayInterval は未送信の書き込みがある間、再送信を試みる最短の間隔です
+const replayInterval = 5 * time.Second
+
+// Client はステータス更新とログの送信に失敗した場合にアウトボックスへ保存するAPIクライアントです
+// 読み取り系の呼び出しはそのまま内側のクライアントに委譲します
+type Client struct {
+	api.KerutaAPI
+	*writer
+	// durable は書き込みの送信に使うクライアントです
+	// デーモンの停止中でもステータスとログが失われないよう、WithContextのctxのキャンセルを引き継ぎません
+	durable api.KerutaAPI
+}
+
+// writer はアウトボックスへの書き込みの状態です
+// WithContextで作成したクライアントとも共有し、書き込みの順序を保ちます
+type writer struct {
+	box *Outbox
+
+	// writeMu は書き込みの順序を保つため、送信とアウトボックスへの保存を直列化します
+	writeMu    sync.Mutex
+	lastReplay time.Time
+}
+
+var _ api.KerutaAPI = (*Client)(nil)
+
+// NewClient はinnerへの書き込みをboxで保護するクライアントを作成します
+func NewClient(inner api.KerutaAPI, box *Outbox) *Client {
+	return &Client{KerutaAPI: inner, writer: &writer{box: box}, durable: inner}
+}
+
+// WithContext はAPI呼び出しにctxのトレースを引き継ぐクライアントを返します
+// 読み取り系の呼び出しはctxのキャンセルで中断しますが、書き込みは中断しません
+// アウトボックスと書き込みの順序は元のクライアントと共有します
+func (c *Client) WithContext(ctx context.Context) api.KerutaAPI {
+	return &Client{
+		KerutaAPI: api.WithContext(c.KerutaAPI, ctx),
+		writer:    c.writer,
+		durable:   api.WithContext(c.durable, context.WithoutCancel(ctx)),
+	}
+}
+
+// Flush は未送信の書き込みをすべて送信します
+func (c *Client) Flush(ctx context.Context) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.lastReplay = time.Now()
+	return c.box.Replay(ctx, api.WithContext(c.durable, ctx))
+}
+
+// Pending は未送信の書き込みの件数を返します
+func (c *Client) Pending() int {
+	return c.box.Len()
+}
+
+// UpdateTaskStatus はタスクのステータスを更新します
+func (c *Client) UpdateTaskStatus(taskID string, status api.TaskStatus, message string, progress int, errorCode string) error {
+	entry := Entry{
+		Kind:   KindStatus,
+		TaskID: taskID,
+		Status: &api.TaskUpdateRequest{
+			Status:    status,
+			Message:   message,
+			Progress:  progress,
+			ErrorCode: errorCode,
+		},
+	}
+	return c.write(entry, func() error {
+		return c.durable.UpdateTaskStatus(taskID, status, message, progress, errorCode)
+	})
+}
+
+// StartTask はタスクを開始します
+func (c *Client) StartTask(taskID string) error {
+	return c.UpdateTaskStatus(taskID, api.TaskStatusProcessing, "タスクを開始しました", 0, "")
+}
+
+// SuccessTask はタスクを成功として完了します
+func (c *Client) SuccessTask(taskID string, message string) error {
+	return c.UpdateTaskStatus(taskID, api.TaskStatusCompleted, message, 100, "")
+}
+
+// FailTask はタスクを失敗として完了します
+func (c *Client) FailTask(taskID string, message string, errorCode string) error {
+	return c.UpdateTaskStatus(taskID, api.TaskStatusFailed, message, 0, errorCode)
+}
+
+// SendLog はログを送信します
+// 送信するログとアウトボックスに保存するログは同じものを使い、再送信しても時刻とシーケンス番号が変わらないようにします
+func (c *Client) SendLog(taskID string, level string, message string) error {
+	logs := []api.LogRequest{api.NewLogRequest(api.LogSourceAgent, level, message)}
+	entry := Entry{
+		Kind:   KindLog,
+		TaskID: taskID,
+		Logs:   logs,
+	}
+	return c.write(entry, func() error {
+		// 1件のログは/logsに送信される
+		return c.durable.SendLogBatch(taskID, logs)
+	})
+}
+
+// SendLogBatch は複数のログをまとめて送信します
+func (c *Client) SendLogBatch(taskID string, logs []api.LogRequest) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	entry := Entry{
+		Kind:   KindLogBatch,
+		TaskID: taskID,
+		Logs:   logs,
+	}
+	return c.write(entry, func() error {
+		return c.durable.SendLogBatch(taskID, logs)
+	})
+}
+
+// write は書き込みを送信し、APIに接続できない場合はアウトボックスに保存します
+// 未送信の書き込みが残っている間は、順番を保つため新しい書き込みも後ろに保存します
+func (c *Client) write(entry Entry, send func() error) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	log := logger.WithComponent("outbox").WithFields(logrus.Fields{
+		"kind":    entry.Kind,
+		"task_id": entry.TaskID,
+	})
+
+	if c.box.Len() > 0 && time.Since(c.lastReplay) >= replayInterval {
+		c.lastReplay = time.Now()
+		if err := c.box.Replay(context.Background(), c.durable); err != nil {
+			log.WithError(err).Debug("未送信の書き込みの再送信に失敗しました")
+		} else {
+			log.Info("未送信の書き込みをすべて再送信しました")
+		}
+	}
+
+	if c.box.Len() == 0 {
+		err := send()
+		if err == nil || !api.IsRetryable(err) {
+			return err
+		}
+		log.WithError(err).Warn("APIに接続できないため、書き込みをアウトボックスに保存して後で再送信します")
+		c.lastReplay = time.Now()
+	}
+
+	if err := c.box.Append(entry); err != nil {
+		return err
+	}
+	return nil
+}
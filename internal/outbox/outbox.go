@@ -0,0 +1,249 @@
+// Package outbox はAPIに送信できなかった書き込み（ステータス更新・ログ）をディスクに保存し、
+// 接続が回復した後に順番通り再送信するための追記型ジャーナルを提供します
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"keruta-agent/internal/api"
+	"keruta-agent/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	journalFileName = "outbox.jsonl"
+	ackFileName     = "outbox.ack"
+)
+
+// Kind は保存された書き込みの種類です
+type Kind string
+
+const (
+	KindStatus   Kind = "status"
+	KindLog      Kind = "log"
+	KindLogBatch Kind = "log_batch"
+)
+
+// Entry はジャーナルに保存される1件の書き込みです
+type Entry struct {
+	Seq       uint64                 `json:"seq"`
+	Kind      Kind                   `json:"kind"`
+	TaskID    string                 `json:"taskId"`
+	Status    *api.TaskUpdateRequest `json:"status,omitempty"`
+	Logs      []api.LogRequest       `json:"logs,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// Outbox は未送信の書き込みを保存する追記型ジャーナルです
+// 送信済みの最後のシーケンス番号を別ファイルに記録し、再送信時の重複を防ぎます
+type Outbox struct {
+	dir string
+
+	mu      sync.Mutex
+	pending []Entry
+	nextSeq uint64
+	acked   uint64
+}
+
+// Open はdirにあるジャーナルを読み込みます。ディレクトリが存在しない場合は作成します
+func Open(dir string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("状態ディレクトリの作成に失敗: %w", err)
+	}
+
+	o := &Outbox{dir: dir}
+	acked, err := o.readAck()
+	if err != nil {
+		return nil, err
+	}
+	o.acked = acked
+	o.nextSeq = acked + 1
+
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// load はジャーナルから送信済みでない書き込みを読み込みます
+func (o *Outbox) load() error {
+	file, err := os.Open(o.journalPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("アウトボックスのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+
+	seen := make(map[uint64]bool)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// 書き込み途中で停止した行は読み飛ばす
+			logger.WithComponent("outbox").WithError(err).Warn("アウトボックスの壊れた行を読み飛ばしました")
+			continue
+		}
+		if entry.Seq >= o.nextSeq {
+			o.nextSeq = entry.Seq + 1
+		}
+		// 送信済み、または重複したシーケンス番号は再送信しない
+		if entry.Seq <= o.acked || seen[entry.Seq] {
+			continue
+		}
+		seen[entry.Seq] = true
+		o.pending = append(o.pending, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("アウトボックスの読み込みに失敗: %w", err)
+	}
+	return nil
+}
+
+// Len は未送信の書き込みの件数を返します
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// Append は書き込みにシーケンス番号を割り当ててジャーナルに追記します
+func (o *Outbox) Append(entry Entry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry.Seq = o.nextSeq
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("アウトボックスのエントリのマーシャルに失敗: %w", err)
+	}
+
+	file, err := os.OpenFile(o.journalPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("アウトボックスのオープンに失敗: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("アウトボックスへの書き込みに失敗: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("アウトボックスの同期に失敗: %w", err)
+	}
+
+	o.nextSeq++
+	o.pending = append(o.pending, entry)
+	return nil
+}
+
+// Replay は未送信の書き込みを古い順にtargetへ送信します
+// 再試行で解決する可能性があるエラーの場合は、順番を保つためそこで中断してエラーを返します
+// それ以外のエラー（タスクが削除された場合など）はその書き込みを破棄して続行します
+func (o *Outbox) Replay(ctx context.Context, target api.KerutaAPI) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	log := logger.WithComponent("outbox")
+	for len(o.pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entry := o.pending[0]
+		if err := deliver(target, entry); err != nil {
+			if api.IsRetryable(err) {
+				return fmt.Errorf("アウトボックスの再送信に失敗 (seq: %d): %w", entry.Seq, err)
+			}
+			log.WithError(err).WithFields(logrus.Fields{
+				"seq":     entry.Seq,
+				"kind":    entry.Kind,
+				"task_id": entry.TaskID,
+			}).Warn("再送信できない書き込みを破棄しました")
+		}
+
+		if err := o.writeAck(entry.Seq); err != nil {
+			return err
+		}
+		o.acked = entry.Seq
+		o.pending = o.pending[1:]
+	}
+
+	// すべて送信済みになったらジャーナルを空にする（シーケンス番号はackファイルで引き継ぐ）
+	if err := os.Remove(o.journalPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.WithError(err).Warn("アウトボックスの削除に失敗しました")
+	}
+	return nil
+}
+
+// deliver は1件の書き込みを送信します
+func deliver(target api.KerutaAPI, entry Entry) error {
+	switch entry.Kind {
+	case KindStatus:
+		if entry.Status == nil {
+			return fmt.Errorf("ステータスが空です")
+		}
+		s := entry.Status
+		return target.UpdateTaskStatus(entry.TaskID, s.Status, s.Message, s.Progress, s.ErrorCode)
+	case KindLog:
+		if len(entry.Logs) == 0 {
+			return nil
+		}
+		// 記録時の時刻とシーケンス番号を保つため、一括送信のAPIで再送信する
+		return target.SendLogBatch(entry.TaskID, entry.Logs)
+	case KindLogBatch:
+		return target.SendLogBatch(entry.TaskID, entry.Logs)
+	default:
+		return fmt.Errorf("不明な種類です: %s", entry.Kind)
+	}
+}
+
+func (o *Outbox) journalPath() string {
+	return filepath.Join(o.dir, journalFileName)
+}
+
+func (o *Outbox) ackPath() string {
+	return filepath.Join(o.dir, ackFileName)
+}
+
+// readAck は送信済みの最後のシーケンス番号を読み込みます
+func (o *Outbox) readAck() (uint64, error) {
+	data, err := os.ReadFile(o.ackPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("アウトボックスの送信済み番号の読み込みに失敗: %w", err)
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("アウトボックスの送信済み番号が不正です: %w", err)
+	}
+	return seq, nil
+}
+
+// writeAck は送信済みの最後のシーケンス番号を一時ファイル経由で書き換えます
+func (o *Outbox) writeAck(seq uint64) error {
+	tmp := o.ackPath() + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(seq, 10)), 0600); err != nil {
+		return fmt.Errorf("アウトボックスの送信済み番号の書き込みに失敗: %w", err)
+	}
+	if err := os.Rename(tmp, o.ackPath()); err != nil {
+		return fmt.Errorf("アウトボックスの送信済み番号の書き込みに失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package outbox
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keruta-agent/internal/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAPI は書き込みを記録するテスト用のAPIクライアントです
+type recordingAPI struct {
+	api.KerutaAPI
+	err      error
+	statuses []api.TaskStatus
+	messages []string
+	// attempts は失敗したものも含め、送信を試みたログです
+	attempts []api.LogRequest
+}
+
+func (r *recordingAPI) UpdateTaskStatus(taskID string, status api.TaskStatus, message string, progress int, errorCode string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func (r *recordingAPI) SendLog(taskID string, level string, message string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *recordingAPI) SendLogBatch(taskID string, logs []api.LogRequest) error {
+	r.attempts = append(r.attempts, logs...)
+	if r.err != nil {
+		return r.err
+	}
+	for _, log := range logs {
+		r.messages = append(r.messages, log.Message)
+	}
+	return nil
+}
+
+// contextAPI はWithContextで束縛したコンテキストがキャンセルされると失敗するテスト用のAPIクライアントです
+type contextAPI struct {
+	*recordingAPI
+	ctx context.Context
+}
+
+func (c *contextAPI) WithContext(ctx context.Context) api.KerutaAPI {
+	return &contextAPI{recordingAPI: c.recordingAPI, ctx: ctx}
+}
+
+func (c *contextAPI) GetTask(taskID string) (*api.Task, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &api.Task{ID: taskID}, nil
+}
+
+func (c *contextAPI) UpdateTaskStatus(taskID string, status api.TaskStatus, message string, progress int, errorCode string) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.recordingAPI.UpdateTaskStatus(taskID, status, message, progress, errorCode)
+}
+
+var errUnavailable = &api.APIError{StatusCode: http.StatusServiceUnavailable}
+
+func TestOutboxPersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	box, err := Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, box.Append(Entry{Kind: KindLog, TaskID: "task-1", Logs: []api.LogRequest{{Level: "INFO", Message: "first"}}}))
+	require.NoError(t, box.Append(Entry{Kind: KindStatus, TaskID: "task-1", Status: &api.TaskUpdateRequest{Status: api.TaskStatusCompleted}}))
+
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+
+	target := &recordingAPI{}
+	require.NoError(t, reopened.Replay(context.Background(), target))
+	assert.Equal(t, []string{"first"}, target.messages)
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusCompleted}, target.statuses)
+
+	// 送信済みの書き込みは再送信しない
+	again, err := Open(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Len())
+
+	// シーケンス番号は引き継がれる
+	require.NoError(t, again.Append(Entry{Kind: KindLog, TaskID: "task-1", Logs: []api.LogRequest{{Message: "third"}}}))
+	assert.Equal(t, uint64(3), again.pending[0].Seq)
+}
+
+func TestOutboxSkipsAckedAndDuplicateEntries(t *testing.T) {
+	dir := t.TempDir()
+	journal := `{"seq":1,"kind":"log","taskId":"t","logs":[{"level":"INFO","message":"acked"}]}
+{"seq":2,"kind":"log","taskId":"t","logs":[{"level":"INFO","message":"second"}]}
+{"seq":2,"kind":"log","taskId":"t","logs":[{"level":"INFO","message":"second"}]}
+{"seq":3,"kind":"log","taskId":"t","logs":[{"level":"INFO","message":"thi`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, journalFileName), []byte(journal), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ackFileName), []byte("1"), 0600))
+
+	box, err := Open(dir)
+	require.NoError(t, err)
+
+	target := &recordingAPI{}
+	require.NoError(t, box.Replay(context.Background(), target))
+	assert.Equal(t, []string{"second"}, target.messages)
+}
+
+func TestOutboxReplayStopsOnRetryableError(t *testing.T) {
+	box, err := Open(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, box.Append(Entry{Kind: KindLog, TaskID: "t", Logs: []api.LogRequest{{Message: "kept"}}}))
+
+	err = box.Replay(context.Background(), &recordingAPI{err: errUnavailable})
+	assert.Error(t, err)
+	assert.Equal(t, 1, box.Len())
+
+	// 再試行しても解決しないエラーは破棄する
+	err = box.Replay(context.Background(), &recordingAPI{err: &api.APIError{StatusCode: http.StatusNotFound}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, box.Len())
+}
+
+func TestClientQueuesWritesWhileAPIUnavailable(t *testing.T) {
+	box, err := Open(t.TempDir())
+	require.NoError(t, err)
+	inner := &recordingAPI{err: errUnavailable}
+	client := NewClient(inner, box)
+
+	// 接続できない間の書き込みは保存され、エラーにはならない
+	require.NoError(t, client.StartTask("task-1"))
+	require.NoError(t, client.SendLog("task-1", "INFO", "hello"))
+	require.NoError(t, client.SendLogBatch("task-1", []api.LogRequest{{Message: "a"}, {Message: "b"}}))
+	assert.Equal(t, 3, client.Pending())
+
+	// 接続が回復したら順番通りに送信する
+	inner.err = nil
+	require.NoError(t, client.Flush(context.Background()))
+	assert.Equal(t, 0, client.Pending())
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusProcessing}, inner.statuses)
+	assert.Equal(t, []string{"hello", "a", "b"}, inner.messages)
+
+	require.NoError(t, client.SuccessTask("task-1", "done"))
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusProcessing, api.TaskStatusCompleted}, inner.statuses)
+
+	// 再試行しても解決しないエラーは保存せずに返す
+	inner.err = &api.APIError{StatusCode: http.StatusBadRequest}
+	assert.Error(t, client.SendLog("task-1", "INFO", "rejected"))
+	assert.Equal(t, 0, client.Pending())
+}
+
+func TestClientWithContextSharesOutbox(t *testing.T) {
+	box, err := Open(t.TempDir())
+	require.NoError(t, err)
+	inner := &recordingAPI{err: errUnavailable}
+	client := NewClient(inner, box)
+
+	// トレースに紐付けたクライアントの書き込みも同じアウトボックスに保存される
+	bound := client.WithContext(context.Background())
+	require.NoError(t, client.SendLog("task-1", "INFO", "first"))
+	require.NoError(t, bound.SendLog("task-1", "INFO", "second"))
+	assert.Equal(t, 2, client.Pending())
+
+	inner.err = nil
+	require.NoError(t, client.Flush(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, inner.messages)
+}
+
+func TestClientWithContextWritesIgnoreCancel(t *testing.T) {
+	box, err := Open(t.TempDir())
+	require.NoError(t, err)
+	inner := &recordingAPI{}
+	client := NewClient(&contextAPI{recordingAPI: inner, ctx: context.Background()}, box)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bound := client.WithContext(ctx)
+
+	// 読み取り系の呼び出しはキャンセルで中断する
+	_, err = bound.GetTask("task-1")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// 書き込みはデーモンの停止中でも送信する
+	require.NoError(t, bound.FailTask("task-1", "interrupted", ""))
+	assert.Equal(t, []api.TaskStatus{api.TaskStatusFailed}, inner.statuses)
+	assert.Equal(t, 0, client.Pending())
+}
+
+func TestClientReplaysSameLog(t *testing.T) {
+	box, err := Open(t.TempDir())
+	require.NoError(t, err)
+	inner := &recordingAPI{err: errUnavailable}
+	client := NewClient(inner, box)
+
+	require.NoError(t, client.SendLog("task-1", "INFO", "hello"))
+	assert.Equal(t, 1, client.Pending())
+
+	// 再送信するログは最初に送信を試みたログと同じ時刻とシーケンス番号を持つ
+	inner.err = nil
+	require.NoError(t, client.Flush(context.Background()))
+	require.Len(t, inner.attempts, 2)
+	assert.Equal(t, inner.attempts[0].Sequence, inner.attempts[1].Sequence)
+	assert.True(t, inner.attempts[0].Timestamp.Equal(inner.attempts[1].Timestamp))
+	assert.Equal(t, []string{"hello"}, inner.messages)
+}
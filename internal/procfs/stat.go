@@ -0,0 +1,77 @@
+//go:build linux
+
+// Package procfs はLinuxの/procファイルシステムからプロセスの情報を読み込みます
+package procfs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Stat は/proc/[pid]/statから読み込んだプロセスの情報です
+type Stat struct {
+	// State はプロセスの状態です（"R"、"S"、"Z"など）
+	State string
+	// Pgrp はプロセスグループIDです
+	Pgrp int
+	// Utime とStime はユーザーモード・カーネルモードで使用したCPU時間です（クロックティック数）
+	Utime uint64
+	Stime uint64
+	// StartTime はプロセスの起動時刻です（起動後のクロックティック数）
+	StartTime uint64
+	// RSS は常駐メモリサイズです（ページ数）
+	RSS int64
+}
+
+// ReadStat は/proc/[pid]/statを読み込みます
+func ReadStat(pid int) (Stat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return Stat{}, err
+	}
+	return ParseStat(string(data))
+}
+
+// ParseStat は/proc/[pid]/statの内容を解析します
+// コマンド名に空白や括弧が含まれる場合があるため、最後の")"より後ろのフィールドを使用します
+func ParseStat(stat string) (Stat, error) {
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return Stat{}, fmt.Errorf("プロセス情報の形式が不正です")
+	}
+	// fields[0]はstate（3番目のフィールド）。pgrpは5番目、utimeは14番目、stimeは15番目、starttimeは22番目、rssは24番目
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return Stat{}, fmt.Errorf("プロセス情報のフィールドが不足しています")
+	}
+	pgrp, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Stat{}, fmt.Errorf("pgrpの解析に失敗: %w", err)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return Stat{}, fmt.Errorf("utimeの解析に失敗: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return Stat{}, fmt.Errorf("stimeの解析に失敗: %w", err)
+	}
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return Stat{}, fmt.Errorf("starttimeの解析に失敗: %w", err)
+	}
+	rss, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return Stat{}, fmt.Errorf("rssの解析に失敗: %w", err)
+	}
+	return Stat{
+		State:     fields[0],
+		Pgrp:      pgrp,
+		Utime:     utime,
+		Stime:     stime,
+		StartTime: startTime,
+		RSS:       rss,
+	}, nil
+}
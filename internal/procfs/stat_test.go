@@ -0,0 +1,29 @@
+//go:build linux
+
+package procfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStat(t *testing.T) {
+	// コマンド名に空白と括弧を含むプロセス
+	stat := "1234 (claude (node)) S 1 1234 1234 0 -1 4194304 100 0 0 0 250 50 0 0 20 0 12 0 5000 1000000 2048 18446744073709551615"
+	parsed, err := ParseStat(stat)
+	require.NoError(t, err)
+	assert.Equal(t, Stat{State: "S", Pgrp: 1234, Utime: 250, Stime: 50, StartTime: 5000, RSS: 2048}, parsed)
+
+	_, err = ParseStat("1234 (claude) S 1")
+	assert.Error(t, err)
+}
+
+func TestReadStatSelf(t *testing.T) {
+	stat, err := ReadStat(os.Getpid())
+	require.NoError(t, err)
+	assert.NotZero(t, stat.StartTime)
+	assert.Positive(t, stat.RSS)
+}
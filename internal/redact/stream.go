@@ -0,0 +1,167 @@
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxBlockSize は複数行にわたる秘密鍵のブロックを保持する最大サイズです
+// これを超えた場合はブロックとして扱わず、1行ずつマスクします
+const maxBlockSize = 64 * 1024
+
+// maxLineSize は1行として保持する最大サイズです
+// 改行を含まない大きなファイルを丸ごとメモリに読み込まないよう、これを超える行はwindowOverlapずつ重ねた範囲に区切ってマスクします
+const maxLineSize = 64 * 1024
+
+// windowOverlap は長い行を区切るときに、次の範囲に持ち越す長さです
+// 秘匿値や認証情報の形式がこれより長い場合は、区切りをまたいだ部分がマスクされないことがあります
+const windowOverlap = 4 * 1024
+
+// Copy はsrcの内容を1行ずつマスクしながらdstに書き込みます
+// ファイル全体をメモリに読み込まずに処理できるため、大きなテキストファイルに使用します
+// 複数行にわたる秘密鍵（-----BEGIN ... PRIVATE KEY-----）はブロック全体をまとめてマスクします
+func (r *Redactor) Copy(dst io.Writer, src io.Reader) (int64, error) {
+	reader := bufio.NewReaderSize(src, maxLineSize)
+	var (
+		written int64
+		block   strings.Builder
+		// long は長い行のうち、まだ書き込んでいない部分です
+		long []byte
+	)
+	write := func(s string) error {
+		n, err := io.WriteString(dst, s)
+		written += int64(n)
+		return err
+	}
+	flushBlock := func() error {
+		if block.Len() == 0 {
+			return nil
+		}
+		text := block.String()
+		block.Reset()
+		return write(r.String(text))
+	}
+	// flushLines は保持していたブロックを、秘密鍵として扱わずに1行ずつマスクして書き込みます
+	flushLines := func() error {
+		text := block.String()
+		block.Reset()
+		for _, l := range strings.SplitAfter(text, "\n") {
+			if err := write(r.String(l)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// 秘密鍵のブロックに長い行は含まれないため、保持していた内容は1行ずつマスクする
+			if block.Len() > 0 {
+				if err := flushLines(); err != nil {
+					return written, err
+				}
+			}
+			long = append(long, chunk...)
+			if len(long) >= maxLineSize+windowOverlap {
+				cut := r.windowCut(long)
+				if err := write(r.String(string(long[:cut]))); err != nil {
+					return written, err
+				}
+				long = append(long[:0], long[cut:]...)
+			}
+			continue
+		}
+		line := string(long) + string(chunk)
+		long = long[:0]
+		if line != "" {
+			switch {
+			case block.Len() > 0:
+				block.WriteString(line)
+				if strings.Contains(line, "PRIVATE KEY-----") && strings.Contains(line, "-----END") {
+					if err := flushBlock(); err != nil {
+						return written, err
+					}
+				} else if block.Len() > maxBlockSize {
+					// 終端が見つからないため、保持していた内容を1行ずつマスクして書き込む
+					if err := flushLines(); err != nil {
+						return written, err
+					}
+				}
+			case strings.Contains(line, "-----BEGIN") && strings.Contains(line, "PRIVATE KEY-----"):
+				block.WriteString(line)
+			default:
+				if err := write(r.String(line)); err != nil {
+					return written, err
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return written, flushBlock()
+			}
+			return written, err
+		}
+	}
+}
+
+// windowCut は長い行のうち、先にマスクして書き込む範囲の終端を返します
+// 末尾のwindowOverlapは次の範囲に持ち越し、秘匿値や認証情報に一致する部分は途中で区切らないよう、その前で区切ります
+func (r *Redactor) windowCut(data []byte) int {
+	if r == nil {
+		return len(data) - windowOverlap
+	}
+	text := string(data)
+	var matches [][]int
+	for _, secret := range r.secrets {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], secret)
+			if i < 0 {
+				break
+			}
+			matches = append(matches, []int{offset + i, offset + i + len(secret)})
+			offset += i + len(secret)
+		}
+	}
+	for _, rule := range r.rules {
+		matches = append(matches, rule.re.FindAllStringIndex(text, -1)...)
+	}
+
+	cut := len(data) - windowOverlap
+	for moved := true; moved; {
+		moved = false
+		for _, m := range matches {
+			if m[0] < cut && cut < m[1] {
+				cut, moved = m[0], true
+			}
+		}
+	}
+	if cut <= 0 {
+		// 範囲全体に一致する場合は、区切らずに読み込み続けないよう固定の位置で区切る
+		return len(data) - windowOverlap
+	}
+	return cut
+}
+
+// Copy はデフォルトのRedactorでsrcの内容をマスクしながらdstに書き込みます
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	return Default().Copy(dst, src)
+}
+
+// IsText はファイルの先頭部分からテキストかどうかを判定します（バイナリはマスクの対象外です）
+// completeがfalseの場合、headは途中で切れている可能性があるため末尾の不完全な文字は無視します
+func IsText(head []byte, complete bool) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	if !complete {
+		for i := 0; i < utf8.UTFMax-1 && len(head) > 0 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	return utf8.Valid(head)
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"keruta-agent/internal/procfs"
+)
+
+// agentRunning はpidのプロセスがこのエージェントと同じ実行ファイルで動作しているかどうかを返します
+// ワークスペースの再起動後にプロセスIDが再利用されても、無関係なプロセスをエージェントと誤認しないよう実行ファイルを比較します
+func agentRunning(pid int) bool {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return false
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	return exe == self
+}
+
+// processStartTime はpidのプロセスの起動時刻（起動後のクロックティック数）を返します。取得できない場合は0を返します
+func processStartTime(pid int) uint64 {
+	stat, err := procfs.ReadStat(pid)
+	if err != nil {
+		return 0
+	}
+	return stat.StartTime
+}
+
+// processGroupRunning はpgidのプロセスグループに動作しているプロセスがあるかどうかを返します
+// 起動時刻は再起動のたびに0から数え直すため大小では比較できません。プロセスグループIDが再利用されていないことは、
+// グループリーダー（PIDがpgidのプロセス）の起動時刻が記録と一致することで確認します
+// リーダーが既に回収されている場合は同じグループか確認できないため、動作していないものとして扱います
+// startTimeが0の場合（起動時刻を記録できなかった場合）は確認を省略します
+func processGroupRunning(pgid int, startTime uint64) bool {
+	leader, err := procfs.ReadStat(pgid)
+	if err != nil || leader.Pgrp != pgid {
+		return false
+	}
+	if startTime != 0 && leader.StartTime != startTime {
+		return false
+	}
+	// 終了して回収を待っているプロセス（ゾンビ）は動作していないものとして扱う
+	if leader.State != "Z" {
+		return true
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := procfs.ReadStat(pid)
+		if err == nil && stat.Pgrp == pgid && stat.State != "Z" {
+			return true
+		}
+	}
+	return false
+}
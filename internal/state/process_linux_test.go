@@ -0,0 +1,48 @@
+//go:build linux
+
+package state
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessGroupRunning(t *testing.T) {
+	path, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep command not available")
+	}
+	cmd := exec.Command(path, "10")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	store, err := Open(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Begin(TaskRecord{TaskID: "task-1"}))
+	require.NoError(t, store.SetProcessGroup("task-1", cmd.Process.Pid))
+	record := store.Current()
+	assert.Equal(t, cmd.Process.Pid, record.ProcessGroup)
+	assert.NotZero(t, record.ProcessStart)
+	assert.True(t, record.ProcessGroupRunning())
+
+	// プロセスグループIDが再利用された場合、グループリーダーの起動時刻が記録と一致しないため対象にしない
+	// 再起動後は起動時刻が0から数え直すため、記録より前でも後でも一致しなければ別のプロセスとして扱う
+	for _, start := range []uint64{record.ProcessStart - 1, record.ProcessStart + 1} {
+		reused := *record
+		reused.ProcessStart = start
+		assert.False(t, reused.ProcessGroupRunning())
+	}
+
+	require.NoError(t, cmd.Process.Kill())
+	_ = cmd.Wait()
+	assert.False(t, record.ProcessGroupRunning())
+	assert.False(t, (&TaskRecord{}).ProcessGroupRunning())
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package state
+
+// agentRunning はpidのプロセスがこのエージェントと同じ実行ファイルで動作しているかどうかを返します
+// Linux以外では確認できないため、常に動作していないものとして扱います
+func agentRunning(pid int) bool {
+	return false
+}
+
+// processStartTime はpidのプロセスの起動時刻を返します
+// Linux以外では取得できないため、常に0を返します
+func processStartTime(pid int) uint64 {
+	return 0
+}
+
+// processGroupRunning はpgidのプロセスグループにプロセスが動作しているかどうかを返します
+// Linux以外では確認できないため、常に動作していないものとして扱います
+func processGroupRunning(pgid int, startTime uint64) bool {
+	return false
+}
@@ -0,0 +1,177 @@
+// Package state はデーモンが実行中のタスクをディスクに記録し、
+// エージェントのクラッシュやワークスペースの再起動の後に、実行中だったタスクを特定するための状態ファイルを提供します
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const fileName = "daemon.json"
+
+// TaskRecord は実行中のタスクの記録です
+type TaskRecord struct {
+	TaskID    string `json:"taskId"`
+	SessionID string `json:"sessionId,omitempty"`
+	// Source はタスクの取得元です（poll: ポーリング、http: 制御用HTTP API）
+	Source string `json:"source"`
+	// PID はタスクを実行していたエージェントのプロセスIDです
+	PID       int       `json:"pid"`
+	Branch    string    `json:"branch,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	// Restarts はこのタスクの実行中にエージェントが再起動した回数です
+	Restarts int `json:"restarts,omitempty"`
+	// ProcessGroup はタスクを実行していた子プロセスのプロセスグループID（子プロセスのPID）です
+	ProcessGroup int `json:"processGroup,omitempty"`
+	// ProcessStart は子プロセスの起動時刻です（起動後のクロックティック数、Linuxのみ）
+	ProcessStart uint64 `json:"processStart,omitempty"`
+}
+
+// OwnerRunning はタスクを実行していたエージェントが、このプロセスとは別にまだ動作しているかどうかを返します
+func (r *TaskRecord) OwnerRunning() bool {
+	return r.PID > 0 && r.PID != os.Getpid() && agentRunning(r.PID)
+}
+
+// ProcessGroupRunning はタスクを実行していた子プロセスのプロセスグループに、まだ動作しているプロセスがあるかどうかを返します
+// エージェントがクラッシュしても子プロセスは別のプロセスグループで動作し続けるため、復旧の前に確認します
+func (r *TaskRecord) ProcessGroupRunning() bool {
+	return r.ProcessGroup > 0 && processGroupRunning(r.ProcessGroup, r.ProcessStart)
+}
+
+// content は状態ファイルの内容です
+type content struct {
+	CurrentTask *TaskRecord `json:"currentTask,omitempty"`
+	// Restarts は再起動後に再開・再キューしたタスクごとの、エージェントが再起動した回数です
+	Restarts map[string]int `json:"restarts,omitempty"`
+}
+
+// Store はデーモンの状態ファイルです
+// 変更のたびに一時ファイル経由でファイル全体を書き換えるため、書き込み途中で停止しても壊れた状態は残りません
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	content content
+}
+
+// Open はdirにある状態ファイルを読み込みます。ディレクトリが存在しない場合は作成します
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("状態ディレクトリの作成に失敗: %w", err)
+	}
+
+	s := &Store{path: filepath.Join(dir, fileName)}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("状態ファイルの読み込みに失敗: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.content); err != nil {
+		return nil, fmt.Errorf("状態ファイルが不正です: %w", err)
+	}
+	return s, nil
+}
+
+// Current は記録されている実行中のタスクを返します（記録がない場合はnil）
+func (s *Store) Current() *TaskRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.content.CurrentTask == nil {
+		return nil
+	}
+	record := *s.content.CurrentTask
+	return &record
+}
+
+// Begin はタスクの実行開始を記録します
+// PIDと開始時刻が設定されていない場合はこのプロセスと現在時刻を記録し、以前の再起動の回数を引き継ぎます
+func (s *Store) Begin(record TaskRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record.PID == 0 {
+		record.PID = os.Getpid()
+	}
+	if record.StartedAt.IsZero() {
+		record.StartedAt = time.Now()
+	}
+	record.Restarts = s.content.Restarts[record.TaskID]
+	s.content.CurrentTask = &record
+	return s.save()
+}
+
+// SetBranch は実行中のタスクのブランチを記録します
+func (s *Store) SetBranch(taskID, branch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.content.CurrentTask == nil || s.content.CurrentTask.TaskID != taskID {
+		return nil
+	}
+	s.content.CurrentTask.Branch = branch
+	return s.save()
+}
+
+// SetProcessGroup は実行中のタスクの子プロセスのプロセスグループIDを記録します
+func (s *Store) SetProcessGroup(taskID string, pgid int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.content.CurrentTask == nil || s.content.CurrentTask.TaskID != taskID {
+		return nil
+	}
+	s.content.CurrentTask.ProcessGroup = pgid
+	s.content.CurrentTask.ProcessStart = processStartTime(pgid)
+	return s.save()
+}
+
+// Finish はタスクの終了を記録し、タスクの再起動の回数を破棄します
+func (s *Store) Finish(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.content.CurrentTask != nil && s.content.CurrentTask.TaskID == taskID {
+		s.content.CurrentTask = nil
+	}
+	delete(s.content.Restarts, taskID)
+	return s.save()
+}
+
+// Restarted は実行中だったタスクを再開・再キューすることを記録し、エージェントが再起動した回数を返します
+// 回数は次にBeginで同じタスクの実行を開始したときに引き継がれます
+func (s *Store) Restarted(taskID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.content.CurrentTask != nil && s.content.CurrentTask.TaskID == taskID {
+		s.content.CurrentTask = nil
+	}
+	if s.content.Restarts == nil {
+		s.content.Restarts = make(map[string]int)
+	}
+	s.content.Restarts[taskID]++
+	return s.content.Restarts[taskID], s.save()
+}
+
+// save は状態ファイルを一時ファイル経由で書き換えます
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("状態ファイルのエンコードに失敗: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("状態ファイルの書き込みに失敗: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("状態ファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package state
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePersistsCurrentTask(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := Open(dir)
+	require.NoError(t, err)
+	assert.Nil(t, store.Current())
+
+	require.NoError(t, store.Begin(TaskRecord{TaskID: "task-1", SessionID: "session-1", Source: "poll"}))
+	require.NoError(t, store.SetBranch("task-1", "keruta/session-1/task-1"))
+	// 別のタスクのブランチは記録しない
+	require.NoError(t, store.SetBranch("task-2", "other"))
+
+	// クラッシュ後に読み込み直しても実行中のタスクが残っている
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	record := reopened.Current()
+	require.NotNil(t, record)
+	assert.Equal(t, "task-1", record.TaskID)
+	assert.Equal(t, "session-1", record.SessionID)
+	assert.Equal(t, "keruta/session-1/task-1", record.Branch)
+	assert.Equal(t, os.Getpid(), record.PID)
+	assert.False(t, record.StartedAt.IsZero())
+	assert.Equal(t, 0, record.Restarts)
+
+	require.NoError(t, reopened.Finish("task-1"))
+	again, err := Open(dir)
+	require.NoError(t, err)
+	assert.Nil(t, again.Current())
+}
+
+func TestStoreCountsRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Begin(TaskRecord{TaskID: "task-1"}))
+	restarts, err := store.Restarted("task-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, restarts)
+	assert.Nil(t, store.Current())
+
+	// 再開したタスクは再起動の回数を引き継ぐ
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Begin(TaskRecord{TaskID: "task-1"}))
+	assert.Equal(t, 1, reopened.Current().Restarts)
+	restarts, err = reopened.Restarted("task-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, restarts)
+
+	// 終了したタスクの回数は破棄する
+	require.NoError(t, reopened.Finish("task-1"))
+	require.NoError(t, reopened.Begin(TaskRecord{TaskID: "task-1"}))
+	assert.Equal(t, 0, reopened.Current().Restarts)
+}
+
+func TestOpenInvalidStateFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, fileName), []byte("{"), 0600))
+
+	_, err := Open(dir)
+	assert.Error(t, err)
+}
+
+func TestOwnerRunning(t *testing.T) {
+	// このプロセス自身と存在しないプロセスは実行中のエージェントとみなさない
+	assert.False(t, (&TaskRecord{PID: os.Getpid()}).OwnerRunning())
+	assert.False(t, (&TaskRecord{}).OwnerRunning())
+
+	// 別の実行ファイルのプロセスはプロセスIDが一致してもエージェントとみなさない
+	path, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep command not available")
+	}
+	cmd := exec.Command(path, "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	assert.False(t, (&TaskRecord{PID: cmd.Process.Pid}).OwnerRunning())
+}
@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"keruta-agent/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// トレースの出力先
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+const (
+	// serviceName はトレースに記録するサービス名です
+	serviceName = "keruta-agent"
+	// tracerName はスパンを作成するトレーサーの名前です
+	tracerName = "keruta-agent"
+)
+
+// Init は設定に従ってトレースの出力先を設定し、W3C Trace Context（traceparent）の伝播を有効にします
+// 返り値の関数は終了時に呼び出し、出力されていないスパンを書き出します
+// 出力先がnoneの場合、スパンは記録されません
+func Init(ctx context.Context, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	exporter, closer, err := newExporter(ctx, config.GetTracingExporter())
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+			attribute.String("service.version", version),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.GetTracingSampleRatio()))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// newExporter は出力先に対応するエクスポーターを作成します
+// fileの場合は終了時に閉じるファイルも返します
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch name {
+	case "", ExporterNone:
+		return nil, nil, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint := config.GetTracingEndpoint(); endpoint != "" {
+			if strings.Contains(endpoint, "://") {
+				opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+			} else {
+				opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+			}
+		}
+		if config.GetTracingInsecure() {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("OTLPエクスポーターの作成に失敗: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, fmt.Errorf("標準出力エクスポーターの作成に失敗: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterFile:
+		path := config.GetTracingFile()
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, nil, fmt.Errorf("トレースの出力先ディレクトリの作成に失敗: %w", err)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("トレースの出力先ファイルのオープンに失敗: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("ファイルエクスポーターの作成に失敗: %w", err)
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("不明なトレースの出力先です: %s（none, otlp, stdout, fileのいずれかを指定してください）", name)
+	}
+}
+
+// Start はctxのスパンを親とする新しいスパンを開始します
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartClient は外部への呼び出し（API呼び出しなど）を表すスパンを開始します
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// End はスパンを終了します。errがnilでない場合はスパンをエラーとして記録します
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// EndHTTP はHTTP呼び出しのスパンをステータスコードとともに終了します
+// 接続エラーと4xx・5xxの応答はエラーとして記録します
+func EndHTTP(span trace.Span, statusCode int, err error) {
+	if statusCode > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+		if err == nil && statusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+	End(span, err)
+}
+
+// Inject はctxのスパンをW3C Trace Context（traceparentヘッダー）としてリクエストヘッダーに設定します
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"keruta-agent/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useRecorder はテスト中のスパンを記録するトレーサープロバイダーを設定します
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	original := otel.GetTracerProvider()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+	return recorder
+}
+
+func TestInitNone(t *testing.T) {
+	config.GlobalConfig = &config.Config{}
+	defer func() { config.GlobalConfig = nil }()
+
+	shutdown, err := Init(context.Background(), "test")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestInitUnknownExporter(t *testing.T) {
+	config.GlobalConfig = &config.Config{Tracing: config.TracingConfig{Exporter: "jaeger"}}
+	defer func() { config.GlobalConfig = nil }()
+
+	_, err := Init(context.Background(), "test")
+	assert.ErrorContains(t, err, "jaeger")
+}
+
+func TestInitFileExporter(t *testing.T) {
+	original := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(original)
+
+	path := filepath.Join(t.TempDir(), "traces", "traces.jsonl")
+	config.GlobalConfig = &config.Config{Tracing: config.TracingConfig{Exporter: ExporterFile, File: path}}
+	defer func() { config.GlobalConfig = nil }()
+
+	shutdown, err := Init(context.Background(), "test")
+	require.NoError(t, err)
+
+	ctx, parent := Start(context.Background(), "task")
+	_, child := Start(ctx, "git.clone_or_pull")
+	End(child, nil)
+	End(parent, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"task"`)
+	assert.Contains(t, string(data), `"Name":"git.clone_or_pull"`)
+	assert.Contains(t, string(data), "keruta-agent")
+}
+
+func TestEnd(t *testing.T) {
+	recorder := useRecorder(t)
+
+	_, span := Start(context.Background(), "task.execute")
+	End(span, errors.New("実行に失敗"))
+	_, span = StartClient(context.Background(), "GET /api/v1/tasks/{id}")
+	EndHTTP(span, http.StatusServiceUnavailable, nil)
+	_, span = StartClient(context.Background(), "GET /api/v1/sessions/{id}")
+	EndHTTP(span, http.StatusOK, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, codes.Unset, spans[2].Status().Code)
+}
+
+func TestInject(t *testing.T) {
+	useRecorder(t)
+	config.GlobalConfig = &config.Config{}
+	defer func() { config.GlobalConfig = nil }()
+	_, err := Init(context.Background(), "test")
+	require.NoError(t, err)
+
+	ctx, span := Start(context.Background(), "poll")
+	defer span.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.Contains(t, header.Get("traceparent"), span.SpanContext().TraceID().String())
+
+	// スパンがない場合は何も設定しない
+	header = http.Header{}
+	Inject(context.Background(), header)
+	assert.Empty(t, header.Get("traceparent"))
+}
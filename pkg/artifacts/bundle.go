@@ -0,0 +1,381 @@
+package artifacts
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"keruta-agent/internal/config"
+	"keruta-agent/internal/logger"
+	"keruta-agent/internal/redact"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mode は成果物のアップロード方法です
+type Mode string
+
+const (
+	// ModeFiles はファイルごとにアップロードします
+	ModeFiles Mode = "files"
+	// ModeBundle は収集したファイルを1つのアーカイブにまとめてアップロードします
+	ModeBundle Mode = "bundle"
+	// ModeAuto は成果物の件数がしきい値を超えた場合にアーカイブにまとめます
+	ModeAuto Mode = "auto"
+)
+
+// BundleFormat は成果物をまとめるアーカイブの形式です
+type BundleFormat string
+
+const (
+	// BundleFormatTarGz はgzipで圧縮したtar形式です
+	BundleFormatTarGz BundleFormat = "tar.gz"
+	// BundleFormatZip はzip形式です
+	BundleFormatZip BundleFormat = "zip"
+)
+
+// ManifestName はアーカイブに含めるマニフェストのファイル名です
+const ManifestName = "manifest.json"
+
+// sniffSize はテキストファイルの判定とMIMEタイプの推定に使う先頭のサイズです
+const sniffSize = 8 * 1024
+
+// BundleOptions は成果物をアーカイブにまとめるかどうかの設定です
+type BundleOptions struct {
+	Mode   Mode
+	Format BundleFormat
+	// Threshold はModeAutoでアーカイブにまとめる件数です（この件数を超えるとまとめます）
+	Threshold int
+}
+
+// ManifestEntry はアーカイブに含めたファイルの情報です
+type ManifestEntry struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+	MimeType string `json:"mimeType"`
+}
+
+// Manifest はアーカイブに含めたファイルの一覧です
+type Manifest struct {
+	Format    BundleFormat    `json:"format"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// ParseMode はアップロード方法の文字列を解析します
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case ModeFiles, ModeBundle, ModeAuto:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("成果物のアップロード方法が不正です (files, bundle, autoのいずれかを指定してください): %s", value)
+	}
+}
+
+// ParseBundleFormat はアーカイブ形式の文字列を解析します
+func ParseBundleFormat(value string) (BundleFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), ".")) {
+	case "tar.gz", "tgz":
+		return BundleFormatTarGz, nil
+	case "zip":
+		return BundleFormatZip, nil
+	default:
+		return "", fmt.Errorf("成果物のアーカイブ形式が不正です (tar.gz, zipのいずれかを指定してください): %s", value)
+	}
+}
+
+// ConfigBundleOptions は設定から成果物をまとめる設定を作成します
+// 設定値が不正な場合は警告を記録してデフォルト値を使用します
+func ConfigBundleOptions() BundleOptions {
+	options := BundleOptions{
+		Mode:      ModeAuto,
+		Format:    BundleFormatTarGz,
+		Threshold: config.GetArtifactsBundleThreshold(),
+	}
+	if mode, err := ParseMode(config.GetArtifactsMode()); err == nil {
+		options.Mode = mode
+	} else {
+		logger.WithComponent("artifacts").WithError(err).Warn("成果物のアップロード方法が不正なため、デフォルト値を使用します")
+	}
+	if format, err := ParseBundleFormat(config.GetArtifactsBundleFormat()); err == nil {
+		options.Format = format
+	} else {
+		logger.WithComponent("artifacts").WithError(err).Warn("成果物のアーカイブ形式が不正なため、デフォルト値を使用します")
+	}
+	return options
+}
+
+// BundleOptionsFromParameters はタスクのパラメータで上書きした設定を返します
+// artifacts_mode、artifacts_bundle_format、artifacts_bundle_thresholdで指定します
+// 不正な値がある場合はその値を無視し、エラーを返します
+func BundleOptionsFromParameters(base BundleOptions, params map[string]interface{}) (BundleOptions, error) {
+	options := base
+	var errs []error
+	if value, ok := params["artifacts_mode"].(string); ok {
+		if mode, err := ParseMode(value); err == nil {
+			options.Mode = mode
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	if value, ok := params["artifacts_bundle_format"].(string); ok {
+		if format, err := ParseBundleFormat(value); err == nil {
+			options.Format = format
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	if value, ok := params["artifacts_bundle_threshold"]; ok && value != nil {
+		if threshold, err := parameterInt(value); err == nil && threshold > 0 {
+			options.Threshold = threshold
+		} else {
+			errs = append(errs, fmt.Errorf("成果物をまとめる件数が不正です: %v", value))
+		}
+	}
+	return options, errors.Join(errs...)
+}
+
+// parameterInt はパラメータの値を整数として取得します（JSONの数値はfloat64になります）
+func parameterInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("整数ではありません: %v", v)
+		}
+		return int(v), nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(v))
+	default:
+		return 0, fmt.Errorf("整数ではありません: %v", v)
+	}
+}
+
+// ShouldBundle はcount件の成果物をアーカイブにまとめるかどうかを返します
+func (o BundleOptions) ShouldBundle(count int) bool {
+	switch o.Mode {
+	case ModeBundle:
+		return count > 0
+	case ModeAuto:
+		return o.Threshold > 0 && count > o.Threshold
+	default:
+		return false
+	}
+}
+
+// FileName はアーカイブのファイル名を返します
+func (o BundleOptions) FileName(name string) string {
+	format := o.Format
+	if format == "" {
+		format = BundleFormatTarGz
+	}
+	return name + "." + string(format)
+}
+
+// bundleWriter はアーカイブにファイルを書き込みます
+type bundleWriter interface {
+	// add はファイルを追加し、書き込んだバイト数を返します
+	add(name string, size int64, modTime time.Time, r io.Reader) (int64, error)
+	close() error
+}
+
+// CreateBundle は成果物をdestのアーカイブにまとめ、内容のマニフェストを返します
+// アーカイブ内のパスは成果物ディレクトリからの相対パスで、最後にマニフェスト（ManifestName）を追加します
+// テキストファイルはアップロード時と同様に秘匿情報をマスクしてから追加します
+func CreateBundle(artifacts []Artifact, format BundleFormat, dest string) (*Manifest, error) {
+	for _, artifact := range artifacts {
+		if filepath.ToSlash(artifact.Name) == ManifestName {
+			return nil, fmt.Errorf("マニフェストと同じ名前の成果物があるためアーカイブにまとめられません: %s", artifact.Name)
+		}
+	}
+
+	file, err := os.Create(dest)
+	if err != nil {
+		return nil, fmt.Errorf("アーカイブの作成に失敗: %w", err)
+	}
+
+	manifest, err := writeBundle(file, artifacts, format)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("アーカイブのクローズに失敗: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+		return nil, err
+	}
+
+	logger.WithComponent("artifacts").WithFields(logrus.Fields{
+		"bundle": dest,
+		"format": format,
+		"count":  len(manifest.Files),
+	}).Info("成果物をアーカイブにまとめました")
+	return manifest, nil
+}
+
+func writeBundle(w io.Writer, artifacts []Artifact, format BundleFormat) (*Manifest, error) {
+	var bw bundleWriter
+	switch format {
+	case BundleFormatTarGz:
+		bw = newTarGzWriter(w)
+	case BundleFormatZip:
+		bw = &zipBundleWriter{zw: zip.NewWriter(w)}
+	default:
+		return nil, fmt.Errorf("成果物のアーカイブ形式が不正です: %s", format)
+	}
+
+	manifest := &Manifest{Format: format, CreatedAt: time.Now().UTC(), Files: []ManifestEntry{}}
+	for _, artifact := range artifacts {
+		entry, err := addToBundle(bw, artifact)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("マニフェストのマーシャルに失敗: %w", err)
+	}
+	if _, err := bw.add(ManifestName, int64(len(data)), manifest.CreatedAt, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("マニフェストの書き込みに失敗: %w", err)
+	}
+	if err := bw.close(); err != nil {
+		return nil, fmt.Errorf("アーカイブの書き込みに失敗: %w", err)
+	}
+	return manifest, nil
+}
+
+// addToBundle は1つの成果物をアーカイブに追加し、マニフェストの項目を返します
+func addToBundle(bw bundleWriter, artifact Artifact) (ManifestEntry, error) {
+	name := filepath.ToSlash(artifact.Name)
+	file, err := os.Open(artifact.Path)
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("成果物のオープンに失敗 (%s): %w", name, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("ファイル情報の取得に失敗 (%s): %w", name, err)
+	}
+
+	reader := bufio.NewReaderSize(file, sniffSize)
+	head, err := reader.Peek(sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return ManifestEntry{}, fmt.Errorf("成果物の読み込みに失敗 (%s): %w", name, err)
+	}
+	mimeType := detectMimeType(name, head)
+
+	var content io.Reader = reader
+	size := info.Size()
+	if redact.IsText(head, errors.Is(err, io.EOF)) {
+		// マスク後のサイズをヘッダーに書き込むため、マスクした内容を一時ファイルに書き出す
+		temp, err := os.CreateTemp("", "keruta-bundle-*")
+		if err != nil {
+			return ManifestEntry{}, fmt.Errorf("一時ファイルの作成に失敗: %w", err)
+		}
+		defer func() {
+			temp.Close()
+			os.Remove(temp.Name())
+		}()
+		if size, err = redact.Copy(temp, reader); err != nil {
+			return ManifestEntry{}, fmt.Errorf("成果物の読み込みに失敗 (%s): %w", name, err)
+		}
+		if _, err := temp.Seek(0, io.SeekStart); err != nil {
+			return ManifestEntry{}, fmt.Errorf("一時ファイルの読み込みに失敗: %w", err)
+		}
+		content = temp
+	}
+
+	hash := sha256.New()
+	size, err = bw.add(name, size, info.ModTime(), io.TeeReader(io.LimitReader(content, size), hash))
+	if err != nil {
+		return ManifestEntry{}, fmt.Errorf("成果物のアーカイブへの追加に失敗 (%s): %w", name, err)
+	}
+
+	return ManifestEntry{
+		Path:     name,
+		Size:     size,
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+		MimeType: mimeType,
+	}, nil
+}
+
+// detectMimeType は拡張子から、拡張子で判定できない場合は内容からMIMEタイプを推定します
+func detectMimeType(name string, head []byte) string {
+	if mimeType := mime.TypeByExtension(path.Ext(name)); mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(head)
+}
+
+// tarGzBundleWriter はtar.gz形式のアーカイブを書き込みます
+type tarGzBundleWriter struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzWriter(w io.Writer) *tarGzBundleWriter {
+	gw := gzip.NewWriter(w)
+	return &tarGzBundleWriter{gw: gw, tw: tar.NewWriter(gw)}
+}
+
+func (b *tarGzBundleWriter) add(name string, size int64, modTime time.Time, r io.Reader) (int64, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  modTime,
+	}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return 0, err
+	}
+	// 読み取り中にファイルが小さくなった場合はtar.Writerのクローズ時にエラーになります
+	return io.Copy(b.tw, r)
+}
+
+func (b *tarGzBundleWriter) close() error {
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gw.Close()
+}
+
+// zipBundleWriter はzip形式のアーカイブを書き込みます
+type zipBundleWriter struct {
+	zw *zip.Writer
+}
+
+func (b *zipBundleWriter) add(name string, _ int64, modTime time.Time, r io.Reader) (int64, error) {
+	w, err := b.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, r)
+}
+
+func (b *zipBundleWriter) close() error {
+	return b.zw.Close()
+}
@@ -0,0 +1,252 @@
+package artifacts
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultExcludePatterns は常に成果物から除外するパターンです（依存パッケージやビルドキャッシュ）
+var DefaultExcludePatterns = []string{
+	"node_modules/",
+	".venv/",
+	"venv/",
+	"__pycache__/",
+	".git/",
+}
+
+// Filter は収集する成果物を絞り込む条件です
+// パターンは.gitignoreと同じ形式で、成果物ディレクトリからの相対パスに対して評価されます
+//   - "/"を含まないパターン（*.log）は任意の階層のファイル・ディレクトリ名に一致します
+//   - "/"を含むパターン（reports/*.md）は成果物ディレクトリからのパスに一致します
+//   - 末尾が"/"のパターン（node_modules/）はディレクトリとその配下のみに一致します
+//   - "**"は0個以上のディレクトリに一致し、先頭が"!"のパターンは直前までの一致を打ち消します
+type Filter struct {
+	// Extensions は収集するファイルの拡張子です（空の場合はすべて）
+	Extensions []string
+	// Include は収集するファイルのパターンです（空の場合はすべて）
+	Include []string
+	// Exclude は除外するファイル・ディレクトリのパターンです
+	Exclude []string
+}
+
+// compiledFilter は評価用に変換したFilterです
+type compiledFilter struct {
+	extensions map[string]bool
+	include    ruleSet
+	exclude    ruleSet
+}
+
+// compile はFilterを評価用に変換します
+func (f Filter) compile() (*compiledFilter, error) {
+	c := &compiledFilter{}
+	for _, ext := range f.Extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if c.extensions == nil {
+			c.extensions = make(map[string]bool)
+		}
+		c.extensions[ext] = true
+	}
+
+	var err error
+	if c.include, err = compileRules(f.Include); err != nil {
+		return nil, err
+	}
+	if c.exclude, err = compileRules(f.Exclude); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// skipDir はディレクトリを走査対象から外すかどうかを返します
+func (c *compiledFilter) skipDir(rel string) bool {
+	return c != nil && c.exclude.match(rel, true)
+}
+
+// allowFile はファイルを成果物として収集するかどうかを返します
+func (c *compiledFilter) allowFile(rel string) bool {
+	if c == nil {
+		return true
+	}
+	if c.exclude.match(rel, false) {
+		return false
+	}
+	if len(c.include) > 0 && !c.include.match(rel, false) {
+		return false
+	}
+	if c.extensions != nil && !c.extensions[strings.ToLower(path.Ext(rel))] {
+		return false
+	}
+	return true
+}
+
+// rule は1つのパターンを表します
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ruleSet は順番に評価するパターンの集合です。後に書かれたパターンが優先されます
+type ruleSet []rule
+
+func compileRules(patterns []string) (ruleSet, error) {
+	var rules ruleSet
+	for _, pattern := range patterns {
+		r, ok, err := compileRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// compileRule は.gitignore形式のパターンを正規表現に変換します
+func compileRule(pattern string) (rule, bool, error) {
+	p := strings.TrimSpace(pattern)
+	if p == "" || strings.HasPrefix(p, "#") {
+		return rule{}, false, nil
+	}
+
+	var r rule
+	if strings.HasPrefix(p, "!") {
+		r.negate = true
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		r.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return rule{}, false, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "/**") && i+3 == len(p):
+			expr.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return rule{}, false, fmt.Errorf("成果物のパターンが不正です (%s): %w", pattern, err)
+	}
+	r.re = re
+	return r, true, nil
+}
+
+// match はパスがパターンに一致するかどうかを返します
+// パターンに一致するディレクトリの配下にあるパスも一致したものとして扱います
+func (rs ruleSet) match(rel string, isDir bool) bool {
+	rel = strings.Trim(path.Clean(strings.ReplaceAll(rel, "\\", "/")), "/")
+	matched := false
+	for _, r := range rs {
+		if r.matchPath(rel, isDir) {
+			matched = !r.negate
+		}
+	}
+	return matched
+}
+
+func (r rule) matchPath(rel string, isDir bool) bool {
+	if (!r.dirOnly || isDir) && r.re.MatchString(rel) {
+		return true
+	}
+	// 親ディレクトリのいずれかが一致する場合
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if r.re.MatchString(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePatterns はカンマ区切りまたは改行区切りのパターンを分割します
+func ParsePatterns(value string) []string {
+	var patterns []string
+	for _, line := range strings.Split(value, "\n") {
+		for _, item := range strings.Split(line, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				patterns = append(patterns, item)
+			}
+		}
+	}
+	return patterns
+}
+
+// FilterFromParameters はタスクのパラメータで上書きしたFilterを返します
+// artifacts_extensionsとartifacts_includeは設定値を置き換え、artifacts_excludeは設定値に追加されます
+// 値はカンマ区切りの文字列または文字列の配列で指定します
+func FilterFromParameters(base Filter, params map[string]interface{}) Filter {
+	f := Filter{
+		Extensions: append([]string(nil), base.Extensions...),
+		Include:    append([]string(nil), base.Include...),
+		Exclude:    append([]string(nil), base.Exclude...),
+	}
+	if values, ok := parameterList(params, "artifacts_extensions"); ok {
+		f.Extensions = values
+	}
+	if values, ok := parameterList(params, "artifacts_include"); ok {
+		f.Include = values
+	}
+	if values, ok := parameterList(params, "artifacts_exclude"); ok {
+		f.Exclude = append(f.Exclude, values...)
+	}
+	return f
+}
+
+// parameterList はパラメータの値を文字列のリストとして取得します
+func parameterList(params map[string]interface{}, key string) ([]string, bool) {
+	value, ok := params[key]
+	if !ok || value == nil {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case string:
+		return ParsePatterns(v), true
+	case []string:
+		return v, true
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				values = append(values, strings.TrimSpace(s))
+			}
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,106 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterPatterns(t *testing.T) {
+	filter, err := Filter{
+		Exclude: []string{"node_modules/", "*.tmp", "/build", "logs/**/*.log", "!logs/keep/important.log"},
+	}.compile()
+	require.NoError(t, err)
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"report.md", true},
+		{"node_modules/pkg/index.js", false},
+		{"web/node_modules/pkg/index.js", false},
+		{"cache.tmp", false},
+		{"sub/cache.tmp", false},
+		{"build/output.bin", false},
+		// 先頭が"/"のパターンは成果物ディレクトリ直下のみに一致する
+		{"sub/build/output.bin", true},
+		{"logs/a/b/debug.log", false},
+		{"logs/debug.log", false},
+		{"logs/keep/important.log", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, filter.allowFile(tt.path), tt.path)
+	}
+
+	assert.True(t, filter.skipDir("node_modules"))
+	assert.True(t, filter.skipDir("web/node_modules"))
+	assert.False(t, filter.skipDir("web"))
+}
+
+func TestFilterIncludeAndExtensions(t *testing.T) {
+	filter, err := Filter{
+		Extensions: []string{"md", ".PDF"},
+		Include:    []string{"reports/", "summary.*"},
+	}.compile()
+	require.NoError(t, err)
+
+	assert.True(t, filter.allowFile("reports/2024/monthly.pdf"))
+	assert.True(t, filter.allowFile("summary.md"))
+	assert.True(t, filter.allowFile("sub/summary.MD"))
+	// 収集パターンに一致しない
+	assert.False(t, filter.allowFile("notes.md"))
+	// 拡張子が許可されていない
+	assert.False(t, filter.allowFile("reports/data.json"))
+}
+
+func TestFilterFromParameters(t *testing.T) {
+	base := Filter{Extensions: []string{".md"}, Exclude: []string{"node_modules/"}}
+
+	f := FilterFromParameters(base, map[string]interface{}{
+		"artifacts_extensions": "pdf, png",
+		"artifacts_include":    []interface{}{"out/", 1},
+		"artifacts_exclude":    "*.tmp\n.cache/",
+	})
+	assert.Equal(t, []string{"pdf", "png"}, f.Extensions)
+	assert.Equal(t, []string{"out/"}, f.Include)
+	assert.Equal(t, []string{"node_modules/", "*.tmp", ".cache/"}, f.Exclude)
+
+	// 元のFilterは変更しない
+	assert.Equal(t, []string{"node_modules/"}, base.Exclude)
+	assert.Equal(t, base, FilterFromParameters(base, nil))
+}
+
+func TestCollectArtifactsWithFilter(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"report.md",
+		"image.png",
+		"node_modules/pkg/readme.md",
+		".venv/lib/site.md",
+		"docs/guide.md",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+	}
+
+	manager := &Manager{directory: dir, maxSize: 1024}
+	require.NoError(t, manager.SetFilter(Filter{
+		Extensions: []string{".md"},
+		Exclude:    append(append([]string(nil), DefaultExcludePatterns...), "docs/"),
+	}))
+
+	artifacts, err := manager.CollectArtifacts()
+	require.NoError(t, err)
+
+	var names []string
+	for _, artifact := range artifacts {
+		names = append(names, filepath.ToSlash(artifact.Name))
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"report.md"}, names)
+}
@@ -5,7 +5,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"keruta-agent/internal/config"
 	"keruta-agent/internal/logger"
@@ -17,6 +19,8 @@ import (
 type Manager struct {
 	directory string
 	maxSize   int64
+	// filter はnilの場合、すべてのファイルを収集します
+	filter *compiledFilter
 }
 
 // Artifact は成果物の情報を表します
@@ -29,10 +33,34 @@ type Artifact struct {
 
 // NewManager は新しい成果物マネージャーを作成します
 func NewManager() *Manager {
-	return &Manager{
-		directory: config.GlobalConfig.Artifacts.Directory,
-		maxSize:   config.GlobalConfig.Artifacts.MaxSize,
+	m := &Manager{
+		directory: config.GetArtifactsDirectory(),
+		maxSize:   config.GetArtifactsMaxSize(),
 	}
+	if err := m.SetFilter(ConfigFilter()); err != nil {
+		logger.WithComponent("artifacts").WithError(err).Warn("成果物のパターンが不正なため、デフォルトの除外パターンのみを使用します")
+		_ = m.SetFilter(Filter{Exclude: DefaultExcludePatterns})
+	}
+	return m
+}
+
+// ConfigFilter は設定から成果物のFilterを作成します。DefaultExcludePatternsは常に含まれます
+func ConfigFilter() Filter {
+	return Filter{
+		Extensions: config.GetArtifactsExtensions(),
+		Include:    config.GetArtifactsInclude(),
+		Exclude:    append(append([]string(nil), DefaultExcludePatterns...), config.GetArtifactsExclude()...),
+	}
+}
+
+// SetFilter は収集する成果物を絞り込む条件を設定します
+func (m *Manager) SetFilter(filter Filter) error {
+	compiled, err := filter.compile()
+	if err != nil {
+		return err
+	}
+	m.filter = compiled
+	return nil
 }
 
 // CollectArtifacts は指定されたディレクトリから成果物を収集します
@@ -52,8 +80,19 @@ func (m *Manager) CollectArtifacts() ([]Artifact, error) {
 			return err
 		}
 
-		// ディレクトリはスキップ
+		// 相対パスを計算
+		relPath, err := filepath.Rel(m.directory, path)
+		if err != nil {
+			logger.WithComponent("artifacts").WithError(err).WithField("path", path).Warn("相対パスの計算に失敗")
+			return nil
+		}
+
+		// ディレクトリはスキップ（除外パターンに一致する場合は配下も走査しない）
 		if d.IsDir() {
+			if relPath != "." && m.filter.skipDir(filepath.ToSlash(relPath)) {
+				logger.WithComponent("artifacts").WithField("path", relPath).Debug("除外パターンに一致するディレクトリをスキップします")
+				return fs.SkipDir
+			}
 			return nil
 		}
 
@@ -62,6 +101,12 @@ func (m *Manager) CollectArtifacts() ([]Artifact, error) {
 			return nil
 		}
 
+		// 拡張子・収集パターン・除外パターンに一致しないファイルはスキップ
+		if !m.filter.allowFile(filepath.ToSlash(relPath)) {
+			logger.WithComponent("artifacts").WithField("path", relPath).Debug("成果物のパターンに一致しないファイルをスキップします")
+			return nil
+		}
+
 		// ファイル情報を取得
 		info, err := d.Info()
 		if err != nil {
@@ -79,13 +124,6 @@ func (m *Manager) CollectArtifacts() ([]Artifact, error) {
 			return nil
 		}
 
-		// 相対パスを計算
-		relPath, err := filepath.Rel(m.directory, path)
-		if err != nil {
-			logger.WithComponent("artifacts").WithError(err).WithField("path", path).Warn("相対パスの計算に失敗")
-			return nil
-		}
-
 		artifact := Artifact{
 			Path: path,
 			Name: relPath,
@@ -171,4 +209,97 @@ func (m *Manager) CleanupArtifacts() error {
 
 	logger.WithComponent("artifacts").WithField("directory", m.directory).Info("成果物ディレクトリをクリーンアップしました")
 	return nil
-} 
\ No newline at end of file
+}
+
+// ArchiveDirectory は成果物ディレクトリのアーカイブを保存するディレクトリを返します
+// 名前の変更だけで移動できるよう、成果物ディレクトリと同じ階層に作成します
+func (m *Manager) ArchiveDirectory() string {
+	return filepath.Clean(m.directory) + ".archive"
+}
+
+// RotateArtifacts は成果物ディレクトリの内容をアーカイブに移動し、次のタスクで再度アップロードされないようにします
+// アーカイブはnameのディレクトリに保存し、新しいものからkeep件を残して削除します
+// 移動できなかったファイルは削除します
+func (m *Manager) RotateArtifacts(name string, keep int) error {
+	entries, err := os.ReadDir(m.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("成果物ディレクトリの読み込みに失敗: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	dest := filepath.Join(m.ArchiveDirectory(), name)
+	if _, err := os.Stat(dest); err == nil {
+		dest = fmt.Sprintf("%s-%d", dest, time.Now().UnixNano())
+	}
+	archived := true
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		logger.WithComponent("artifacts").WithError(err).WithField("archive", dest).Warn("アーカイブディレクトリの作成に失敗したため、成果物を削除します")
+		archived = false
+	}
+
+	for _, entry := range entries {
+		src := filepath.Join(m.directory, entry.Name())
+		if archived {
+			err := os.Rename(src, filepath.Join(dest, entry.Name()))
+			if err == nil {
+				continue
+			}
+			logger.WithComponent("artifacts").WithError(err).WithField("path", src).Warn("成果物をアーカイブに移動できなかったため削除します")
+		}
+		if err := os.RemoveAll(src); err != nil {
+			return fmt.Errorf("成果物の削除に失敗: %w", err)
+		}
+	}
+
+	logger.WithComponent("artifacts").WithFields(logrus.Fields{
+		"directory": m.directory,
+		"archive":   dest,
+	}).Info("成果物ディレクトリをローテーションしました")
+
+	return m.pruneArchives(keep)
+}
+
+// pruneArchives は古いアーカイブを削除し、新しいものからkeep件を残します
+func (m *Manager) pruneArchives(keep int) error {
+	entries, err := os.ReadDir(m.ArchiveDirectory())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("アーカイブディレクトリの読み込みに失敗: %w", err)
+	}
+
+	type archive struct {
+		path    string
+		modTime time.Time
+	}
+	var archives []archive
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		archives = append(archives, archive{filepath.Join(m.ArchiveDirectory(), entry.Name()), info.ModTime()})
+	}
+	if len(archives) <= keep {
+		return nil
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].modTime.After(archives[j].modTime)
+	})
+	for _, old := range archives[keep:] {
+		if err := os.RemoveAll(old.path); err != nil {
+			return fmt.Errorf("古いアーカイブの削除に失敗: %w", err)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"keruta-agent/internal/config"
 
@@ -310,4 +311,44 @@ func TestCollectArtifactsWithSubdirectories(t *testing.T) {
 	names := []string{artifacts[0].Name, artifacts[1].Name}
 	assert.Contains(t, names, "rootfile.txt")
 	assert.Contains(t, names, "subdir/subfile.txt")
-} 
\ No newline at end of file
+}
+
+func TestRotateArtifacts(t *testing.T) {
+	root := t.TempDir()
+	directory := filepath.Join(root, "doc")
+	manager := &Manager{directory: directory, maxSize: 100 * 1024 * 1024}
+
+	// ディレクトリが存在しない場合は何もしない
+	require.NoError(t, manager.RotateArtifacts("task-0", 2))
+
+	for i, name := range []string{"task-1", "task-2", "task-3"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(directory, "sub"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "report.md"), []byte(name), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "sub", "data.json"), []byte("{}"), 0644))
+
+		require.NoError(t, manager.RotateArtifacts(name, 2))
+
+		// 次のタスクでは成果物として収集されない
+		artifacts, err := manager.CollectArtifacts()
+		require.NoError(t, err)
+		assert.Empty(t, artifacts)
+
+		content, err := os.ReadFile(filepath.Join(manager.ArchiveDirectory(), name, "report.md"))
+		require.NoError(t, err)
+		assert.Equal(t, name, string(content))
+		assert.FileExists(t, filepath.Join(manager.ArchiveDirectory(), name, "sub", "data.json"))
+
+		// 更新時刻が同じにならないよう、作成した順に古い時刻を設定する
+		past := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(manager.ArchiveDirectory(), name), past, past))
+	}
+
+	// 新しいものから2件だけ残る
+	entries, err := os.ReadDir(manager.ArchiveDirectory())
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"task-2", "task-3"}, names)
+}
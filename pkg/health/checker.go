@@ -1,10 +1,14 @@
 package health
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
+	"os/exec"
 	"runtime"
+	"strings"
 	"time"
 
 	"keruta-agent/internal/api"
@@ -12,9 +16,38 @@ import (
 	"keruta-agent/internal/logger"
 )
 
+// Thresholds はヘルスチェックで異常とみなす閾値です
+type Thresholds struct {
+	// MinFreeDisk はディスクの最小空き容量（バイト）です
+	MinFreeDisk uint64
+	// MinFreeDiskPercent はディスクの最小空き容量の割合（%）です
+	MinFreeDiskPercent float64
+	// MinAvailableMemory は使用可能なメモリの最小値（バイト）です
+	MinAvailableMemory uint64
+	// MinAvailableMemoryPercent は使用可能なメモリの最小の割合（%）です
+	MinAvailableMemoryPercent float64
+}
+
+// DefaultThresholds はヘルスチェックのデフォルトの閾値を返します
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MinFreeDisk:               512 * megabyte,
+		MinFreeDiskPercent:        5,
+		MinAvailableMemory:        256 * megabyte,
+		MinAvailableMemoryPercent: 5,
+	}
+}
+
 // Checker はヘルスチェックを担当します
 type Checker struct {
-	apiClient *api.Client
+	apiClient api.KerutaAPI
+	// prober は認証付きエンドポイントでトークンを確認するクライアントです
+	prober     authProber
+	thresholds Thresholds
+	// memInfoPath はシステムのメモリ情報を読み込むファイルです
+	memInfoPath string
+	// tools は存在とバージョンを確認するコマンドです
+	tools []string
 }
 
 // HealthStatus はヘルスチェックの結果を表します
@@ -29,15 +62,32 @@ type CheckResult struct {
 	Status  bool   `json:"status"`
 	Message string `json:"message"`
 	Error   string `json:"error,omitempty"`
+	// Details は確認した値の詳細です（空き容量やコマンドのバージョンなど）
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// authProber は認証付きエンドポイントを呼び出してトークンを確認します
+type authProber interface {
+	ProbeAuth(path string) *api.ProbeResult
 }
 
 // NewChecker は新しいヘルスチェッカーを作成します
 func NewChecker() *Checker {
+	client := api.NewClient()
 	return &Checker{
-		apiClient: api.NewClient(),
+		apiClient:   client,
+		prober:      client,
+		thresholds:  DefaultThresholds(),
+		memInfoPath: defaultMemInfoPath,
+		tools:       DefaultTools,
 	}
 }
 
+// SetThresholds は異常とみなす閾値を設定します
+func (c *Checker) SetThresholds(thresholds Thresholds) {
+	c.thresholds = thresholds
+}
+
 // CheckAll は全てのヘルスチェックを実行します
 func (c *Checker) CheckAll() *HealthStatus {
 	status := &HealthStatus{
@@ -53,6 +103,13 @@ func (c *Checker) CheckAll() *HealthStatus {
 		status.Overall = false
 	}
 
+	// APIトークンの認証チェック（Podのプローブなど、確認対象のIDがない場合はスキップ）
+	authResult := c.checkAuthIfTargeted()
+	status.Checks["auth"] = authResult
+	if !authResult.Status {
+		status.Overall = false
+	}
+
 	// ディスク容量チェック
 	diskResult := c.CheckDisk()
 	status.Checks["disk"] = diskResult
@@ -67,13 +124,20 @@ func (c *Checker) CheckAll() *HealthStatus {
 		status.Overall = false
 	}
 
-	// 設定チェック
-	configResult := c.CheckConfig()
+	// 設定チェック（タスクの外からも実行できるよう、タスクIDなどは必須としない）
+	configResult := c.checkConfig(false)
 	status.Checks["config"] = configResult
 	if !configResult.Status {
 		status.Overall = false
 	}
 
+	// 依存コマンドチェック
+	toolsResult := c.CheckTools()
+	status.Checks["tools"] = toolsResult
+	if !toolsResult.Status {
+		status.Overall = false
+	}
+
 	logger.WithComponent("health").WithField("overall", status.Overall).Info("ヘルスチェックが完了しました")
 	return status
 }
@@ -116,12 +180,84 @@ func (c *Checker) CheckAPI() CheckResult {
 	}
 }
 
-// CheckDisk はディスク容量をチェックします
+// CheckAuth は設定されたトークンで認証付きエンドポイントを呼び出し、
+// APIに到達できるか・トークンが有効か・リソースへのアクセスが許可されているかをチェックします
+func (c *Checker) CheckAuth() CheckResult {
+	logger.WithComponent("health").Debug("APIトークンをチェック中")
+
+	path, err := authProbePath()
+	if err != nil {
+		return CheckResult{
+			Status:  false,
+			Message: "APIトークンを確認できません",
+			Error:   err.Error(),
+		}
+	}
+
+	result := c.prober.ProbeAuth(path)
+	details := map[string]interface{}{
+		"level":      string(result.Level),
+		"endpoint":   path,
+		"latency_ms": result.Latency.Milliseconds(),
+	}
+	if result.StatusCode != 0 {
+		details["status_code"] = result.StatusCode
+	}
+	if result.TLSExpiry != nil {
+		details["tls_expiry"] = result.TLSExpiry.UTC().Format(time.RFC3339)
+		details["tls_expires_in_days"] = int(time.Until(*result.TLSExpiry).Hours() / 24)
+	}
+
+	check := CheckResult{Details: details}
+	if result.Err != nil {
+		check.Error = result.Err.Error()
+	}
+
+	switch {
+	case result.Level == api.AuthLevelUnreachable:
+		check.Message = "API接続に失敗しました"
+	case result.TokenRejected():
+		check.Message = "APIトークンが拒否されました（無効または期限切れ）"
+	case result.StatusCode == http.StatusNotFound:
+		check.Message = "APIトークンは有効ですが、確認したリソースが見つかりません"
+	case result.Level == api.AuthLevelAuthenticated:
+		check.Message = "APIトークンは有効ですが、リソースへのアクセス権限がありません"
+	case result.Level != api.AuthLevelAuthorized:
+		check.Message = fmt.Sprintf("API応答が異常です: %d", result.StatusCode)
+	default:
+		check.Status = true
+		check.Message = fmt.Sprintf("APIトークンは有効です（応答時間 %dms）", result.Latency.Milliseconds())
+		if result.TLSExpiry != nil && time.Until(*result.TLSExpiry) < tlsExpiryWarning {
+			check.Message += fmt.Sprintf("。TLS証明書の有効期限が近づいています: %s", result.TLSExpiry.Format("2006-01-02"))
+		}
+	}
+	return check
+}
+
+// checkAuthIfTargeted はタスク・セッション・ワークスペースのIDが設定されている場合にCheckAuthを実行します
+// IDが設定されていない場合は、トークンを確認できないためスキップします
+func (c *Checker) checkAuthIfTargeted() CheckResult {
+	if _, err := authProbePath(); err != nil {
+		return CheckResult{
+			Status:  true,
+			Message: "認証を確認するためのIDが設定されていないため、チェックをスキップしました",
+			Error:   err.Error(),
+		}
+	}
+	return c.CheckAuth()
+}
+
+// authProbePath は設定されたタスク・セッション・ワークスペースのIDから、認証を確認するエンドポイントを返します
+func authProbePath() (string, error) {
+	return api.ProbePath(config.GetTaskID(), os.Getenv("KERUTA_SESSION_ID"), config.GetWorkspaceID())
+}
+
+// CheckDisk は成果物ディレクトリと作業ディレクトリのファイルシステムの空き容量をチェックします
 func (c *Checker) CheckDisk() CheckResult {
 	logger.WithComponent("health").Debug("ディスク容量をチェック中")
 
 	// 成果物ディレクトリの容量をチェック
-	dir := config.GlobalConfig.Artifacts.Directory
+	dir := config.GetArtifactsDirectory()
 
 	// ディレクトリが存在しない場合は作成を試行
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
@@ -134,21 +270,93 @@ func (c *Checker) CheckDisk() CheckResult {
 		}
 	}
 
-	// ディスク容量の確認（簡易版）
-	// 実際の実装では、より詳細なディスク容量チェックを行う
+	details := make(map[string]interface{})
+	var problems []string
+	for _, path := range []string{dir, workingDirectory()} {
+		if _, checked := details[path]; checked {
+			continue
+		}
+		total, free, err := diskUsage(existingDirectory(path))
+		if errors.Is(err, errDiskUsageUnsupported) {
+			return CheckResult{
+				Status:  true,
+				Message: "ディスク容量を確認できないため、チェックをスキップしました",
+				Error:   err.Error(),
+			}
+		}
+		if err != nil {
+			return CheckResult{
+				Status:  false,
+				Message: fmt.Sprintf("ディスク容量の取得に失敗しました: %s", path),
+				Error:   err.Error(),
+			}
+		}
+
+		freePercent := percentOf(free, total)
+		details[path] = map[string]interface{}{
+			"free_mb":      free / megabyte,
+			"total_mb":     total / megabyte,
+			"free_percent": math.Round(freePercent*10) / 10,
+		}
+		if free < c.thresholds.MinFreeDisk || freePercent < c.thresholds.MinFreeDiskPercent {
+			problems = append(problems, fmt.Sprintf("%s (空き %d MB, %.1f%%)", path, free/megabyte, freePercent))
+		}
+	}
+
+	if len(problems) > 0 {
+		return CheckResult{
+			Status:  false,
+			Message: "ディスクの空き容量が不足しています: " + strings.Join(problems, ", "),
+			Details: details,
+		}
+	}
 	return CheckResult{
 		Status:  true,
 		Message: "ディスク容量は十分です",
+		Details: details,
 	}
 }
 
-// CheckMemory はメモリ使用量をチェックします
+// CheckMemory はシステムの使用可能なメモリをチェックします
+// /proc/meminfoを読み込めない環境では、このプロセスのメモリ使用量をチェックします
 func (c *Checker) CheckMemory() CheckResult {
 	logger.WithComponent("health").Debug("メモリ使用量をチェック中")
 
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
+	info, err := readMemInfo(c.memInfoPath)
+	if err != nil {
+		logger.WithComponent("health").WithError(err).Debug("システムのメモリ情報を取得できないため、プロセスのメモリ使用量をチェックします")
+		return checkProcessMemory(m)
+	}
+
+	total := info["MemTotal"]
+	available := info.available()
+	availablePercent := percentOf(available, total)
+	details := map[string]interface{}{
+		"total_mb":          total / megabyte,
+		"available_mb":      available / megabyte,
+		"available_percent": math.Round(availablePercent*10) / 10,
+		"process_heap_mb":   m.Alloc / megabyte,
+	}
+
+	if available < c.thresholds.MinAvailableMemory || availablePercent < c.thresholds.MinAvailableMemoryPercent {
+		return CheckResult{
+			Status:  false,
+			Message: fmt.Sprintf("使用可能なメモリが不足しています: %d MB / %d MB (%.1f%%)", available/megabyte, total/megabyte, availablePercent),
+			Details: details,
+		}
+	}
+	return CheckResult{
+		Status:  true,
+		Message: fmt.Sprintf("メモリ使用量は正常です: 使用可能 %d MB / %d MB", available/megabyte, total/megabyte),
+		Details: details,
+	}
+}
+
+// checkProcessMemory はこのプロセスのメモリ使用量をチェックします
+func checkProcessMemory(m runtime.MemStats) CheckResult {
 	// メモリ使用量の閾値（例: 1GB）
 	maxMemory := uint64(1024 * 1024 * 1024)
 
@@ -165,8 +373,50 @@ func (c *Checker) CheckMemory() CheckResult {
 	}
 }
 
+// CheckTools はタスクの実行に必要なコマンド（claude、git、tmux）が存在するかをチェックし、バージョンを取得します
+func (c *Checker) CheckTools() CheckResult {
+	logger.WithComponent("health").Debug("依存コマンドをチェック中")
+
+	details := make(map[string]interface{})
+	var missing []string
+	for _, tool := range c.tools {
+		path, err := exec.LookPath(tool)
+		if err != nil {
+			missing = append(missing, tool)
+			details[tool] = map[string]interface{}{"found": false}
+			continue
+		}
+
+		detail := map[string]interface{}{"found": true, "path": path}
+		if version, err := toolVersion(tool, path); err == nil {
+			detail["version"] = version
+		} else {
+			detail["version_error"] = err.Error()
+		}
+		details[tool] = detail
+	}
+
+	if len(missing) > 0 {
+		return CheckResult{
+			Status:  false,
+			Message: "必要なコマンドが見つかりません: " + strings.Join(missing, ", "),
+			Details: details,
+		}
+	}
+	return CheckResult{
+		Status:  true,
+		Message: "必要なコマンドはすべて利用可能です",
+		Details: details,
+	}
+}
+
 // CheckConfig は設定をチェックします
 func (c *Checker) CheckConfig() CheckResult {
+	return c.checkConfig(true)
+}
+
+// checkConfig は設定をチェックします。requireTargetがtrueの場合はタスクIDなどの設定も必須とします
+func (c *Checker) checkConfig(requireTarget bool) CheckResult {
 	logger.WithComponent("health").Debug("設定をチェック中")
 
 	// 必須設定のチェック
@@ -184,7 +434,9 @@ func (c *Checker) CheckConfig() CheckResult {
 		}
 	}
 
-	if config.GetTaskID() == "" {
+	// デーモンモードではタスクIDの代わりにセッションIDまたはワークスペースIDで動作する
+	hasDaemonTarget := os.Getenv("KERUTA_SESSION_ID") != "" || config.GetWorkspaceID() != "" || config.GetCoderWorkspaceName() != ""
+	if requireTarget && config.GetTaskID() == "" && !hasDaemonTarget {
 		return CheckResult{
 			Status:  false,
 			Message: "タスクIDが設定されていません",
@@ -202,12 +454,16 @@ func (c *Checker) CheckSpecific(checkType string) CheckResult {
 	switch checkType {
 	case "api":
 		return c.CheckAPI()
+	case "auth":
+		return c.CheckAuth()
 	case "disk":
 		return c.CheckDisk()
 	case "memory":
 		return c.CheckMemory()
 	case "config":
 		return c.CheckConfig()
+	case "tools":
+		return c.CheckTools()
 	default:
 		return CheckResult{
 			Status:  false,
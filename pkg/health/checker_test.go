@@ -4,10 +4,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 	"time"
 
+	"keruta-agent/internal/api"
 	"keruta-agent/internal/config"
 
 	"github.com/stretchr/testify/assert"
@@ -49,13 +51,15 @@ func TestCheckAll(t *testing.T) {
 	assert.NotNil(t, status)
 	assert.False(t, status.Overall) // API接続が失敗するため
 	assert.NotZero(t, status.Timestamp)
-	assert.Len(t, status.Checks, 4)
+	assert.Len(t, status.Checks, 6)
 	
 	// 各チェックが実行されていることを確認
 	assert.Contains(t, status.Checks, "api")
+	assert.Contains(t, status.Checks, "auth")
 	assert.Contains(t, status.Checks, "disk")
 	assert.Contains(t, status.Checks, "memory")
 	assert.Contains(t, status.Checks, "config")
+	assert.Contains(t, status.Checks, "tools")
 }
 
 func TestCheckAPI(t *testing.T) {
@@ -302,6 +306,7 @@ func TestCheckSpecific(t *testing.T) {
 		expected  bool
 	}{
 		{"api", false},     // API接続が失敗するため
+		{"auth", false},    // API接続が失敗するため
 		{"disk", true},     // ディスクチェックは成功
 		{"memory", true},   // メモリチェックは成功
 		{"config", true},   // 設定チェックは成功
@@ -352,4 +357,150 @@ func TestCheckResult(t *testing.T) {
 	assert.False(t, failureResult.Status)
 	assert.Equal(t, "Failure message", failureResult.Message)
 	assert.Equal(t, "Error details", failureResult.Error)
-} 
\ No newline at end of file
+} 
+
+func TestCheckDiskThreshold(t *testing.T) {
+	config.GlobalConfig = &config.Config{
+		Artifacts: config.ArtifactsConfig{
+			Directory: t.TempDir(),
+		},
+	}
+	t.Setenv("KERUTA_WORKING_DIR", t.TempDir())
+
+	checker := NewChecker()
+	// 実行環境の空き容量に依存しないよう、閾値を小さくする
+	checker.SetThresholds(Thresholds{MinFreeDisk: 1})
+	result := checker.CheckDisk()
+	require.True(t, result.Status)
+	assert.Len(t, result.Details, 2)
+
+	// 空き容量の閾値を実際の容量より大きくすると異常になる
+	checker.SetThresholds(Thresholds{MinFreeDisk: 1 << 62})
+	result = checker.CheckDisk()
+	assert.False(t, result.Status)
+	assert.Contains(t, result.Message, "ディスクの空き容量が不足しています")
+}
+
+func TestCheckMemoryFromMemInfo(t *testing.T) {
+	memInfo := filepath.Join(t.TempDir(), "meminfo")
+	require.NoError(t, os.WriteFile(memInfo, []byte("MemTotal:        8388608 kB\nMemFree:          102400 kB\nMemAvailable:    4194304 kB\n"), 0644))
+
+	checker := NewChecker()
+	checker.memInfoPath = memInfo
+
+	result := checker.CheckMemory()
+	assert.True(t, result.Status)
+	assert.Equal(t, "メモリ使用量は正常です: 使用可能 4096 MB / 8192 MB", result.Message)
+	assert.Equal(t, uint64(4096), result.Details["available_mb"])
+
+	checker.SetThresholds(Thresholds{MinAvailableMemory: 5000 * megabyte})
+	result = checker.CheckMemory()
+	assert.False(t, result.Status)
+	assert.Contains(t, result.Message, "使用可能なメモリが不足しています")
+}
+
+func TestReadMemInfoWithoutMemAvailable(t *testing.T) {
+	memInfo := filepath.Join(t.TempDir(), "meminfo")
+	require.NoError(t, os.WriteFile(memInfo, []byte("MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 50 kB\nCached: 250 kB\n"), 0644))
+
+	info, err := readMemInfo(memInfo)
+	require.NoError(t, err)
+	// MemAvailableがない場合はMemFree、Buffers、Cachedの合計で代用する
+	assert.Equal(t, uint64(400*1024), info.available())
+}
+
+func TestCheckTools(t *testing.T) {
+	bin := t.TempDir()
+	writeTool := func(name, output string) {
+		script := "#!/bin/sh\necho '" + output + "'\n"
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte(script), 0755))
+	}
+	writeTool("git", "git version 2.43.0")
+	writeTool("tmux", "tmux 3.4")
+	t.Setenv("PATH", bin)
+
+	checker := NewChecker()
+	result := checker.CheckTools()
+	assert.False(t, result.Status)
+	assert.Equal(t, "必要なコマンドが見つかりません: claude", result.Message)
+	assert.Equal(t, "git version 2.43.0", result.Details["git"].(map[string]interface{})["version"])
+	assert.Equal(t, "tmux 3.4", result.Details["tmux"].(map[string]interface{})["version"])
+
+	writeTool("claude", "1.0.0")
+	result = checker.CheckTools()
+	assert.True(t, result.Status)
+	assert.Equal(t, "1.0.0", result.Details["claude"].(map[string]interface{})["version"])
+}
+
+func TestCheckAuth(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/workspaces/workspace-1/tasks/pending", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	config.GlobalConfig = &config.Config{
+		API: config.APIConfig{
+			URL:   server.URL,
+			Token: "test-token",
+		},
+	}
+	t.Setenv("KERUTA_TASK_ID", "")
+	t.Setenv("KERUTA_SESSION_ID", "")
+	t.Setenv("KERUTA_WORKSPACE_ID", "workspace-1")
+
+	checker := NewChecker()
+	result := checker.CheckAuth()
+	assert.True(t, result.Status)
+	assert.Contains(t, result.Message, "APIトークンは有効です")
+	assert.Equal(t, "authorized", result.Details["level"])
+
+	status = http.StatusUnauthorized
+	result = checker.CheckAuth()
+	assert.False(t, result.Status)
+	assert.Equal(t, "APIトークンが拒否されました（無効または期限切れ）", result.Message)
+	assert.Equal(t, "reachable", result.Details["level"])
+
+	status = http.StatusForbidden
+	result = checker.CheckAuth()
+	assert.False(t, result.Status)
+	assert.Equal(t, "APIトークンは有効ですが、リソースへのアクセス権限がありません", result.Message)
+	assert.Equal(t, "authenticated", result.Details["level"])
+
+	status = http.StatusNotFound
+	result = checker.CheckAuth()
+	assert.False(t, result.Status)
+	assert.Equal(t, "APIトークンは有効ですが、確認したリソースが見つかりません", result.Message)
+	assert.Equal(t, "authenticated", result.Details["level"])
+}
+
+func TestCheckAuthTLSExpiry(t *testing.T) {
+	config.GlobalConfig = &config.Config{}
+	t.Setenv("KERUTA_TASK_ID", "test-task-123")
+
+	expiry := time.Now().Add(72 * time.Hour)
+	checker := NewChecker()
+	checker.prober = fakeProber{result: &api.ProbeResult{
+		Level:      api.AuthLevelAuthorized,
+		StatusCode: http.StatusOK,
+		Latency:    15 * time.Millisecond,
+		TLSExpiry:  &expiry,
+	}}
+
+	result := checker.CheckAuth()
+	assert.True(t, result.Status)
+	assert.Contains(t, result.Message, "TLS証明書の有効期限が近づいています")
+	assert.Equal(t, int64(15), result.Details["latency_ms"])
+	assert.Equal(t, 2, result.Details["tls_expires_in_days"])
+}
+
+// fakeProber は決まった結果を返す認証確認用のクライアントです
+type fakeProber struct {
+	result *api.ProbeResult
+}
+
+func (p fakeProber) ProbeAuth(path string) *api.ProbeResult {
+	return p.result
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package health
+
+// diskUsage はこのOSでは対応していないため、常にerrDiskUsageUnsupportedを返します
+func diskUsage(string) (total, free uint64, err error) {
+	return 0, 0, errDiskUsageUnsupported
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+
+package health
+
+import "syscall"
+
+// diskUsage はpathを含むファイルシステムの容量と空き容量（バイト）を返します
+// 空き容量は一般ユーザーが使用できる容量です
+func diskUsage(path string) (total, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(st.Bsize)
+	return uint64(st.Blocks) * blockSize, uint64(st.Bavail) * blockSize, nil
+}
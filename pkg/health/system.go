@@ -0,0 +1,144 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	megabyte = 1024 * 1024
+	// defaultMemInfoPath はシステムのメモリ情報を読み込むファイルです
+	defaultMemInfoPath = "/proc/meminfo"
+	// toolVersionTimeout はコマンドのバージョン取得を待つ時間です
+	toolVersionTimeout = 5 * time.Second
+	// tlsExpiryWarning はTLS証明書の有効期限が近いと警告する残り期間です
+	tlsExpiryWarning = 14 * 24 * time.Hour
+)
+
+// DefaultTools は存在とバージョンを確認するコマンドです
+var DefaultTools = []string{"claude", "git", "tmux"}
+
+// toolVersionArgs はバージョンを表示する引数が--versionではないコマンドの引数です
+var toolVersionArgs = map[string][]string{
+	"tmux": {"-V"},
+}
+
+// errDiskUsageUnsupported はこのOSではディスク容量を取得できないことを表します
+var errDiskUsageUnsupported = errors.New("このOSではディスク容量を取得できません")
+
+// workingDirectory はタスクの作業ディレクトリのベースを返します
+// git.DetermineWorkingDirectoryと同じく、KERUTA_WORKING_DIR、KERUTA_BASE_DIR、~/kerutaの順に決定します
+func workingDirectory() string {
+	if dir := os.Getenv("KERUTA_WORKING_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("KERUTA_BASE_DIR"); dir != "" {
+		return dir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "keruta")
+	}
+	return "/tmp/keruta"
+}
+
+// existingDirectory はpathまたはその親ディレクトリのうち、存在する最も近いディレクトリを返します
+// まだ作成されていないディレクトリでも、作成先のファイルシステムの容量を確認できるようにします
+func existingDirectory(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// memInfo は/proc/meminfoの内容（バイト）です
+type memInfo map[string]uint64
+
+// readMemInfo は/proc/meminfo形式のファイルを読み込みます
+func readMemInfo(path string) (memInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info := make(memInfo)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && strings.EqualFold(fields[1], "kB") {
+			n *= 1024
+		}
+		info[key] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if info["MemTotal"] == 0 {
+		return nil, fmt.Errorf("MemTotalが見つかりません: %s", path)
+	}
+	return info, nil
+}
+
+// available は使用可能なメモリ（バイト）を返します
+// MemAvailableがない古いカーネルではMemFree、Buffers、Cachedの合計で代用します
+func (m memInfo) available() uint64 {
+	if available, ok := m["MemAvailable"]; ok {
+		return available
+	}
+	return m["MemFree"] + m["Buffers"] + m["Cached"]
+}
+
+// toolVersion はコマンドのバージョン表示の1行目を返します
+func toolVersion(name, path string) (string, error) {
+	args, ok := toolVersionArgs[name]
+	if !ok {
+		args = []string{"--version"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), toolVersionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+	return "", nil
+}
+
+// percentOf はpartがtotalに占める割合（%）を返します
+func percentOf(part, total uint64) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(part) / float64(total) * 100
+}